@@ -0,0 +1,220 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nucleuscloud/cli/internal/archive"
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/projecttoml"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Builds the code bundle for a service without deploying it.",
+	Long:  "Builds the code bundle for a service exactly as deploy would, writes it to disk, and prints what went into it. The bundle can then be deployed with 'nucleus deploy --archive'.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nucleusConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+
+		serviceName, err := cmd.Flags().GetString("service")
+		if err != nil {
+			return err
+		}
+		outputPath, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		top, err := cmd.Flags().GetInt("top")
+		if err != nil {
+			return err
+		}
+		if top < 0 {
+			return fmt.Errorf("top must not be negative")
+		}
+
+		serviceConfigs, err := config.GetServiceConfigs(nucleusConfig)
+		if err != nil {
+			return err
+		}
+		serviceNames := []string{}
+		if serviceName != "" {
+			serviceNames = append(serviceNames, serviceName)
+		}
+		serviceConfigs, err = config.SelectServiceConfigs(serviceConfigs, serviceNames)
+		if err != nil {
+			return err
+		}
+		svc := serviceConfigs[0]
+		if svc.Spec.ServiceRunTime == "docker" {
+			return fmt.Errorf("service %s uses a docker image, no code is bundled", svc.Spec.ServiceName)
+		}
+		if outputPath == "" {
+			outputPath = getDefaultBundleName(svc.Spec.ServiceName)
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		directoryName, err := config.GetServiceDirectory(svc)
+		if err != nil {
+			return err
+		}
+		projectFile, err := getServiceProjectFile(directoryName)
+		if err != nil {
+			return err
+		}
+		var includes, excludes []string
+		if projectFile != nil {
+			includes, excludes = projecttoml.GetIncludeExclude(projectFile)
+		}
+
+		filters := getArchiveFilters(directoryName, svc.Spec.ServiceName, includes, excludes)
+		// never bundle the bundle itself, or a stale one from a previous run
+		absOutputPath, err := filepath.Abs(outputPath)
+		if err != nil {
+			return err
+		}
+		if relPath, err := filepath.Rel(directoryName, absOutputPath); err == nil && !strings.HasPrefix(relPath, "..") {
+			filters = append(filters, archive.IgnorePatterns("/"+filepath.ToSlash(relPath)))
+		}
+
+		summary, err := writeBundle(outputPath, directoryName, filters)
+		if err != nil {
+			return err
+		}
+		printArchiveWarnings(svc.Spec.ServiceName, summary)
+		printBundleSummary(summary, outputPath, top)
+		return printBundleSecrets(outputPath, directoryName)
+	},
+}
+
+// Returns the name bundle writes the service's bundle to when no output is given
+func getDefaultBundleName(serviceName string) string {
+	return fmt.Sprintf("%s.tar.gz", serviceName)
+}
+
+// Archives the directory into the output path, removing the partially written file on failure
+func writeBundle(outputPath string, directoryName string, filters []archive.Filter) (*archive.Summary, error) {
+	fd, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := archive.GzipDirectory(directoryName, fd, filters...)
+	if err == nil {
+		err = fd.Sync()
+	}
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+	return summary, nil
+}
+
+func printBundleSummary(summary *archive.Summary, outputPath string, top int) {
+	for _, file := range summary.Files {
+		fmt.Println(file.Name)
+	}
+	fmt.Println()
+	fmt.Printf("Wrote %s\n", outputPath)
+	fmt.Printf("Files:      %d\n", len(summary.Files))
+	fmt.Printf("Total size: %s\n", formatBytes(summary.TotalSize))
+	fmt.Printf("Compressed: %s\n", formatBytes(summary.CompressedSize))
+	fmt.Printf("Hash:       %s\n", summary.Hash)
+
+	if top == 0 || len(summary.Files) == 0 {
+		return
+	}
+	fmt.Println()
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	tbl := table.New("Size", "File")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+	for _, file := range getLargestFiles(summary.Files, top) {
+		tbl.AddRow(formatBytes(file.Size), file.Name)
+	}
+	tbl.Print()
+}
+
+// Returns up to top files, largest first
+func getLargestFiles(files []*archive.File, top int) []*archive.File {
+	largest := make([]*archive.File, len(files))
+	copy(largest, files)
+	sort.SliceStable(largest, func(i, j int) bool {
+		return largest[i].Size > largest[j].Size
+	})
+	if len(largest) > top {
+		largest = largest[:top]
+	}
+	return largest
+}
+
+// Warns about anything in the bundle that would block a deploy of it
+func printBundleSecrets(outputPath string, directoryName string) error {
+	fd, err := os.Open(outputPath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	findings, err := scanCodeBundle(directoryName, fd)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	fmt.Printf("\nWarning: the bundle may contain secrets, deploying it requires --allow-secrets:\n")
+	for _, finding := range findings {
+		fmt.Printf("  %s\n", finding.String())
+	}
+	return nil
+}
+
+// Formats a byte count using binary units, like 1.5 MiB
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+
+	bundleCmd.Flags().StringP("service", "s", "", "service from the nucleus manifest to bundle")
+	bundleCmd.Flags().StringP("output", "o", "", "path to write the bundle to (default \"<service>.tar.gz\")")
+	bundleCmd.Flags().Int("top", 10, "number of the largest files to list")
+}
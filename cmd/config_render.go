@@ -0,0 +1,103 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/nucleuscloud/cli/internal/config"
+)
+
+var configRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Prints the nucleus manifest as it will be deployed to an environment.",
+	Long:  "Prints the nucleus manifest with all of the environment specific overrides merged over the base spec and all var references resolved. This is the spec that nucleus deploy will use for the given environment.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environmentName, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+		if environmentName == "" {
+			return fmt.Errorf("must provide environment name")
+		}
+
+		serviceNames, err := cmd.Flags().GetStringSlice("service")
+		if err != nil {
+			return err
+		}
+
+		useLocalEnv, err := cmd.Flags().GetBool("local-env")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		nucleusConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+
+		serviceConfigs, err := config.GetServiceConfigs(nucleusConfig)
+		if err != nil {
+			return err
+		}
+		if len(serviceNames) > 0 {
+			serviceConfigs, err = config.SelectServiceConfigs(serviceConfigs, serviceNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		rendered := config.NucleusConfig{
+			CliVersion: nucleusConfig.CliVersion,
+		}
+		for _, svc := range serviceConfigs {
+			spec := config.GetSpecForEnv(&svc.Spec, environmentName)
+			err = resolveSpecVars(spec, environmentName, useLocalEnv)
+			if err != nil {
+				return err
+			}
+			if len(nucleusConfig.Services) == 0 {
+				rendered.Spec = *spec
+			} else {
+				rendered.Services = append(rendered.Services, config.ServiceConfig{
+					Directory: svc.Directory,
+					Spec:      *spec,
+				})
+			}
+		}
+
+		marshalled, err := yaml.Marshal(&rendered)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(marshalled))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configRenderCmd)
+
+	configRenderCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
+	configRenderCmd.Flags().StringSliceP("service", "s", []string{}, "comma separated list of services from the nucleus manifest to render")
+	configRenderCmd.Flags().Bool("local-env", false, "allow vars to reference variables from the local environment")
+}
@@ -0,0 +1,44 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/validate"
+)
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Prints the JSON Schema of the nucleus manifest file.",
+	Long:  "Prints the JSON Schema of the nucleus manifest file. This can be used by editors to provide completion and validation while editing nucleus.yaml.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		schema, err := validate.GetJsonSchema()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(schema))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSchemaCmd)
+}
@@ -82,24 +82,24 @@ func isDocker(dir string) bool {
 }
 
 func guessProjectType() string {
-	cwd, err := os.Getwd()
+	dir, err := config.GetNucleusConfigDir()
 	if err != nil {
 		return utils.GetSupportedRuntimes()[0]
 
 	}
-	if isGolang(cwd) {
+	if isGolang(dir) {
 		return "go"
 	}
-	if isNodejs(cwd) {
+	if isNodejs(dir) {
 		return "nodejs"
 	}
-	if isPython(cwd) {
+	if isPython(dir) {
 		return "python"
 	}
-	if isDocker(cwd) {
+	if isDocker(dir) {
 		return "docker"
 	}
-	if isRuby(cwd) {
+	if isRuby(dir) {
 		return "ruby"
 	}
 	return utils.GetSupportedRuntimes()[0]
@@ -185,16 +185,31 @@ var createServiceCmd = &cobra.Command{
 				return err
 			}
 		} else if svcCommands.ServiceType == "python" {
-			err = ensureProcfileExists()
+			dir, err := config.GetNucleusConfigDir()
+			if err != nil {
+				return err
+			}
+			err = ensureProcfileExists(dir)
+			if err != nil {
+				return err
+			}
+			err = checkProcfile(dir, true)
+			if err != nil {
+				return err
+			}
+		} else {
+			dir, err := config.GetNucleusConfigDir()
+			if err != nil {
+				return err
+			}
+			err = checkProcfile(dir, false)
 			if err != nil {
 				return err
 			}
 		}
 
-		cliVersion := "nucleus-cli/v1alpha1"
-
 		nucleusConfig := config.NucleusConfig{
-			CliVersion: cliVersion,
+			CliVersion: config.CurrentCliVersion,
 			Spec: config.SpecStruct{
 				ServiceName:    svcCommands.ServiceName,
 				ServiceRunTime: svcCommands.ServiceType,
@@ -211,21 +226,23 @@ var createServiceCmd = &cobra.Command{
 	},
 }
 
-func ensureProcfileExists() error {
+func ensureProcfileExists(dir string) error {
 	// ask about proc file if it doesn't exist
-	if !procfile.DoesProcfileExist() {
+	if !procfile.DoesProcfileExist(dir) {
 		var entrypoint string
 		err := survey.AskOne(&survey.Input{
 			Message: "What is the entrypoint to your web server?",
 			Help:    "uvicorn main:app --host 0.0.0.0 --port $PORT",
-		}, &entrypoint)
+		}, &entrypoint, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)) // deploy keeps stdout for json progress
 		if err != nil {
 			return err
 		}
 		if entrypoint == "" {
 			return fmt.Errorf("entrypoint length must be greater than 0")
 		}
-		err = procfile.SetProcfile(&procfile.Procfile{Web: entrypoint})
+		file := &procfile.Procfile{}
+		file.SetProcess(procfile.WebProcessType, entrypoint)
+		err = procfile.SetProcfile(dir, file)
 		if err != nil {
 			return err
 		}
@@ -233,6 +250,29 @@ func ensureProcfileExists() error {
 	return nil
 }
 
+// Reads the Procfile in the directory, if there is one, and prints any malformed lines
+// along with the process types that won't be deployed.
+func checkProcfile(dir string, requireWeb bool) error {
+	if !procfile.DoesProcfileExist(dir) {
+		return nil
+	}
+	file, warnings, err := procfile.GetProcfile(dir)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: skipping Procfile %s\n", warning.String())
+	}
+	if requireWeb && file.GetProcess(procfile.WebProcessType) == nil {
+		return fmt.Errorf("Procfile in %s does not declare a %s process", dir, procfile.WebProcessType)
+	}
+	if extraTypes := file.GetExtraProcessTypes(); len(extraTypes) > 0 {
+		// the manifest has no way to declare other processes yet, so they are only pointed out
+		fmt.Fprintf(os.Stderr, "Warning: Procfile declares process types that are not deployed: %s. Only the %s process is run by nucleus.\n", strings.Join(extraTypes, ", "), procfile.WebProcessType)
+	}
+	return nil
+}
+
 func getDefaultSpec() (*config.SpecStruct, error) {
 	spec := config.SpecStruct{}
 	defaultServiceName, err := getDefaultServiceName()
@@ -245,11 +285,11 @@ func getDefaultSpec() (*config.SpecStruct, error) {
 }
 
 func getDefaultServiceName() (string, error) {
-	wd, err := os.Getwd()
+	dir, err := config.GetNucleusConfigDir()
 	if err != nil {
 		return "", err
 	}
-	defaultDir := strings.ReplaceAll(strings.ToValidUTF8(strings.ToLower(filepath.Base(wd)), ""), "_", "-")
+	defaultDir := strings.ReplaceAll(strings.ToValidUTF8(strings.ToLower(filepath.Base(dir)), ""), "_", "-")
 	return defaultDir, nil
 }
 
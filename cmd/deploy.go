@@ -1,45 +1,61 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	ga "github.com/mhelmich/go-archiver"
 	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
 	"github.com/spf13/cobra"
-	"github.com/vbauerster/mpb/v8"
-	"github.com/vbauerster/mpb/v8/decor"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/nucleuscloud/cli/internal/archive"
 	"github.com/nucleuscloud/cli/internal/config"
 	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/interpolate"
+	"github.com/nucleuscloud/cli/internal/procfile"
 	"github.com/nucleuscloud/cli/internal/progress"
 	"github.com/nucleuscloud/cli/internal/projecttoml"
 	"github.com/nucleuscloud/cli/internal/secrets"
+	"github.com/nucleuscloud/cli/internal/secretscan"
+	"github.com/nucleuscloud/cli/internal/upload"
 	"github.com/nucleuscloud/cli/internal/utils"
+	"github.com/nucleuscloud/cli/internal/validate"
 )
 
-type ProgressBar struct {
-	abort      bool
-	currentInt int64
-}
+var (
+	// nucleus closed the deploy stream without a service url or a failed pipeline, so the outcome isn't known
+	errDeployStreamEnded = errors.New("deploy stream ended without a service url")
+)
 
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
 	Short: "Deploys your service to Nucleus and returns an endpoint that you can use to communicate with your newly deployed service.",
-	Long:  `Deploys your service to Nucleus and returns an endpoint that you can use to communicate with your newly deployed service.`,
+	Long: `Deploys your service to Nucleus and returns an endpoint that you can use to communicate with your newly deployed service.
+
+With --progress json, one json event is written to stdout per line as the deploy progresses, and everything else is written to stderr.
+The exit code is 1 if any deploy failed, and 0 otherwise. A cancelled deploy is not a failure, it is reported with a deploy_cancelled event.
+If the service was deployed but its allowed and disallowed services couldn't be set, a policy_failed event follows service_deployed.
+
+Interrupting a running deploy (Ctrl-C) stops the progress display and asks whether to keep watching. The CLI can't cancel a deploy
+once it has started, so when you stop watching it keeps running remotely and the exit code is 1. Interrupting again stops watching
+right away, as does any interrupt in json mode or without a terminal. In json mode a deploy_detached event is emitted.
+
+With --detach, deploy returns as soon as nucleus has accepted the deploy and prints its id and the pipeline run that is deploying it,
+with exit code 0. The deploy is handed to a background process on this machine that stays connected to nucleus until the deploy
+has finished, check on it with deploy status and deploy watch. The machine must stay up until then: stopping the background
+process, for example when a CI runner is torn down, can end the deploy along with it. deploy status and deploy watch read what the
+background process recorded, so they only work on the machine that started the deploy.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
@@ -48,10 +64,6 @@ var deployCmd = &cobra.Command{
 			return err
 		}
 
-		if !utils.IsValidName(deployConfig.Spec.ServiceName) {
-			return utils.ErrInvalidServiceName
-		}
-
 		environmentName, err := cmd.Flags().GetString("env")
 		if err != nil {
 			return err
@@ -60,60 +72,128 @@ var deployCmd = &cobra.Command{
 			return fmt.Errorf("must provide environment name")
 		}
 
-		serviceName := deployConfig.Spec.ServiceName
-		if serviceName == "" {
-			return fmt.Errorf("service name not provided")
+		deployAll, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		serviceNames, err := cmd.Flags().GetStringSlice("service")
+		if err != nil {
+			return err
+		}
+		if deployAll && len(serviceNames) > 0 {
+			return fmt.Errorf("must provide either --all or --service, not both")
 		}
 
-		serviceType := deployConfig.Spec.ServiceRunTime
-		if serviceType == "" {
-			return fmt.Errorf("service type not provided")
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			return err
 		}
-		if !utils.IsValidRuntime(serviceType) {
-			return fmt.Errorf("must provide valid service runtime")
+		if concurrency < 1 {
+			return fmt.Errorf("concurrency must be greater than 0")
 		}
 
-		// Set this after ensuring flags are correct
-		cmd.SilenceUsage = true
+		serviceConfigs, err := config.GetServiceConfigs(deployConfig)
+		if err != nil {
+			return err
+		}
+		if !deployAll {
+			serviceConfigs, err = config.SelectServiceConfigs(serviceConfigs, serviceNames)
+			if err != nil {
+				return err
+			}
+		}
 
-		if serviceType == "python" {
-			err = ensureProcfileExists()
+		for _, svc := range serviceConfigs {
+			err = validateServiceSpec(&svc.Spec)
 			if err != nil {
 				return err
 			}
 		}
 
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
 		progressType, err := progress.ValidateAndRetrieveProgressFlag(cmd)
 		if err != nil {
 			return err
 		}
 
-		err = validateResources(deployConfig.Spec.Resources)
+		useLocalEnv, err := cmd.Flags().GetBool("local-env")
 		if err != nil {
 			return err
 		}
-
-		directoryName, err := os.Getwd()
+		showIgnored, err := cmd.Flags().GetBool("show-ignored")
 		if err != nil {
 			return err
 		}
-
-		envSecrets := secrets.GetSecretsByEnvName(&deployConfig.Spec, environmentName)
+		forceUpload, err := cmd.Flags().GetBool("force-upload")
+		if err != nil {
+			return err
+		}
+		archivePath, err := cmd.Flags().GetString("archive")
+		if err != nil {
+			return err
+		}
+		allowSecrets, err := cmd.Flags().GetBool("allow-secrets")
+		if err != nil {
+			return err
+		}
+		showBuildLogs, err := cmd.Flags().GetBool("show-build-logs")
 		if err != nil {
 			return err
 		}
+		failureLogsDir, err := cmd.Flags().GetString("failure-logs-dir")
+		if err != nil {
+			return err
+		}
+		detach, err := cmd.Flags().GetBool("detach")
+		if err != nil {
+			return err
+		}
+		if detach && showBuildLogs {
+			return fmt.Errorf("--show-build-logs can't be used with --detach")
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if output != "" && output != "json" {
+			return fmt.Errorf("must provide valid output")
+		}
+		if output != "" && !dryRun {
+			return fmt.Errorf("--output can only be used with --dry-run")
+		}
+		if archivePath != "" && len(serviceConfigs) > 1 {
+			return fmt.Errorf("--archive can only be used when deploying a single service")
+		}
 
-		var buildTimeEnvVars map[string]string
-		if ok := projecttoml.DoesProjectFileExist(projecttoml.ProjectTomlPath); ok {
-			projectFile, err := projecttoml.GetProjectFile(projecttoml.ProjectTomlPath)
-			if err != nil {
-				return err
-			}
-			buildEvs, err := projecttoml.GetBuildEnvVars(projectFile)
+		reqs := []*deployRequest{}
+		for _, svc := range serviceConfigs {
+			req, err := getDeployRequest(deployConfig.CliVersion, environmentName, svc, useLocalEnv, dryRun || showIgnored)
 			if err != nil {
 				return err
 			}
-			buildTimeEnvVars = buildEvs
+			req.forceUpload = forceUpload
+			req.archivePath = archivePath
+			req.allowSecrets = allowSecrets
+			req.showBuildLogs = showBuildLogs
+			req.failureLogsDir = failureLogsDir
+			req.detach = detach
+			reqs = append(reqs, req)
+		}
+		if archivePath != "" && reqs[0].serviceType == "docker" {
+			return fmt.Errorf("--archive can't be used with service %s because it uses a docker image", reqs[0].serviceName)
+		}
+
+		if showIgnored {
+			return printIgnoredFiles(reqs)
+		}
+		if dryRun {
+			return printDryRun(reqs, output)
 		}
 
 		conn, err := utils.NewApiConnectionByEnv(ctx, clienv.GetEnv())
@@ -124,84 +204,160 @@ var deployCmd = &cobra.Command{
 
 		svcClient := svcmgmtv1alpha1.NewServiceMgmtServiceClient(conn)
 
-		req := deployRequest{
-			cliVersion:       deployConfig.CliVersion,
-			environmentName:  environmentName,
-			serviceName:      serviceName,
-			serviceType:      serviceType,
-			image:            deployConfig.Spec.Image,
-			folderPath:       directoryName,
-			isPrivateService: deployConfig.Spec.IsPrivate,
-			envVars:          deployConfig.Spec.Vars,
-			envSecrets:       envSecrets,
-			resources:        deployConfig.Spec.Resources,
-			buildTimeEnvVars: buildTimeEnvVars,
-		}
-		err = deploy(ctx, svcClient, req, progressType)
-		if err != nil {
-			return err
+		if len(reqs) > 1 {
+			return deployServices(ctx, svcClient, reqs, progressType, concurrency)
 		}
-		return setAuthzPolicy(
-			ctx,
-			svcClient,
-			environmentName,
-			serviceName,
-			deployConfig.Spec.AllowedServices,
-			deployConfig.Spec.DisallowedServices,
-		)
+
+		req := reqs[0]
+		return deploy(ctx, svcClient, *req, progressType)
 	},
 }
 
-func validateResources(reqs config.ResourceRequirements) error {
-	if reqs.Minimum.Cpu != "" {
-		_, err := mustParseResource(reqs.Minimum.Cpu)
-		if err != nil {
-			return fmt.Errorf(fmt.Sprintf("minimum cpu is not valid: %s", err.Error()))
-		}
+func validateServiceSpec(spec *config.SpecStruct) error {
+	if !utils.IsValidName(spec.ServiceName) {
+		return utils.ErrInvalidServiceName
 	}
-	if reqs.Minimum.Memory != "" {
-		_, err := mustParseResource(reqs.Minimum.Memory)
-		if err != nil {
-			return fmt.Errorf(fmt.Sprintf("minimum memory is not valid: %s", err.Error()))
+
+	if spec.ServiceName == "" {
+		return fmt.Errorf("service name not provided")
+	}
+
+	if spec.ServiceRunTime == "" {
+		return fmt.Errorf("service type not provided")
+	}
+	if !utils.IsValidRuntime(spec.ServiceRunTime) {
+		return fmt.Errorf("must provide valid service runtime")
+	}
+	return nil
+}
+
+// Returns a warning for every var key that can't be used as an environment variable name, sorted by key
+func getVarKeyWarnings(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	warnings := []string{}
+	for _, key := range keys {
+		if err := utils.ValidateVarKey(key); err != nil {
+			warnings = append(warnings, err.Error())
 		}
 	}
-	if reqs.Maximum.Cpu != "" {
-		_, err := mustParseResource(reqs.Maximum.Cpu)
-		if err != nil {
-			return fmt.Errorf(fmt.Sprintf("maximum cpu is not valid: %s", err.Error()))
+	return warnings
+}
+
+// Resolves everything needed to deploy a single service from the manifest to the given environment.
+// A dry run never writes to the service directory, a missing Procfile is only reported.
+func getDeployRequest(
+	cliVersion string,
+	environmentName string,
+	svc config.ServiceConfig,
+	useLocalEnv bool,
+	dryRun bool,
+) (*deployRequest, error) {
+	spec := config.GetSpecForEnv(&svc.Spec, environmentName)
+	// invalid keys are enforced by nucleus validate, deploying only points them out
+	for _, warning := range getVarKeyWarnings(spec.Vars) {
+		fmt.Fprintf(os.Stderr, "Warning: service %s: %s (run nucleus validate for details)\n", spec.ServiceName, warning)
+	}
+	err := resolveSpecVars(spec, environmentName, useLocalEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	directoryName, err := config.GetServiceDirectory(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	missingProcfile := false
+	if spec.ServiceRunTime == "python" {
+		if dryRun {
+			missingProcfile = !procfile.DoesProcfileExist(directoryName)
+		} else {
+			err = ensureProcfileExists(directoryName)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
-	if reqs.Maximum.Memory != "" {
-		_, err := mustParseResource(reqs.Maximum.Memory)
+	if spec.ServiceRunTime != "docker" {
+		err = checkProcfile(directoryName, spec.ServiceRunTime == "python")
 		if err != nil {
-			return fmt.Errorf(fmt.Sprintf("maximum memory is not valid: %s", err.Error()))
+			return nil, err
 		}
 	}
 
-	if reqs.Minimum.Cpu != "" && reqs.Maximum.Cpu != "" {
-		minCpu, _ := mustParseResource(reqs.Minimum.Cpu)
-		maxCpu, _ := mustParseResource(reqs.Maximum.Cpu)
+	err = validate.ValidateResources(spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	envSecrets := secrets.GetSecretsByEnvName(spec, environmentName)
 
-		if minCpu.Cmp(*maxCpu) == 1 {
-			return fmt.Errorf("min cpu must be less than max cpu")
-		}
+	var buildTimeEnvVars map[string]string
+	var includes, excludes []string
+	projectFile, err := getServiceProjectFile(directoryName)
+	if err != nil {
+		return nil, err
 	}
-	if reqs.Minimum.Memory != "" && reqs.Maximum.Memory != "" {
-		minMem, _ := mustParseResource(reqs.Minimum.Memory)
-		maxMem, _ := mustParseResource(reqs.Maximum.Memory)
-		if minMem.Cmp(*maxMem) == 1 {
-			return fmt.Errorf("min memory must be less than max memory")
+	if projectFile != nil {
+		buildEvs, err := projecttoml.GetBuildEnvVars(projectFile)
+		if err != nil {
+			return nil, err
+		}
+		buildTimeEnvVars = buildEvs
+		includes, excludes = projecttoml.GetIncludeExclude(projectFile)
+
+		// the deploy request has no field for the buildpack group yet, so the build picks its own buildpacks
+		if group := projecttoml.GetBuildpackGroup(projectFile); len(group) > 0 {
+			names := []string{}
+			for _, bp := range group {
+				names = append(names, bp.String())
+			}
+			fmt.Fprintf(os.Stderr, "Warning: project.toml for %s selects buildpacks (%s), but nucleus can't send a buildpack group with the deploy yet, so it is ignored\n", spec.ServiceName, strings.Join(names, ", "))
 		}
 	}
-	return nil
+
+	return &deployRequest{
+		cliVersion:         cliVersion,
+		environmentName:    environmentName,
+		serviceName:        spec.ServiceName,
+		serviceType:        spec.ServiceRunTime,
+		image:              spec.Image,
+		folderPath:         directoryName,
+		isPrivateService:   spec.IsPrivate,
+		envVars:            spec.Vars,
+		envSecrets:         envSecrets,
+		resources:          spec.Resources,
+		buildTimeEnvVars:   buildTimeEnvVars,
+		includes:           includes,
+		excludes:           excludes,
+		gitSha:             getGitSha(directoryName),
+		allowedServices:    spec.AllowedServices,
+		disallowedServices: spec.DisallowedServices,
+		missingProcfile:    missingProcfile,
+	}, nil
 }
 
-func mustParseResource(val string) (*resource.Quantity, error) {
-	quantity, err := resource.ParseQuantity(val)
+// Replaces the spec's vars with their fully interpolated values
+func resolveSpecVars(spec *config.SpecStruct, environmentName string, useLocalEnv bool) error {
+	opts := &interpolate.Options{
+		BuiltIns: interpolate.GetBuiltIns(environmentName, spec.ServiceName),
+	}
+	if useLocalEnv {
+		opts.LookupEnv = os.LookupEnv
+	}
+	vars, err := interpolate.ResolveVars(spec.Vars, opts)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("unable to resolve vars for service '%s': %w", spec.ServiceName, err)
+	}
+	if spec.Vars != nil {
+		spec.Vars = vars
 	}
-	return &quantity, nil
+	return nil
 }
 
 func setAuthzPolicy(
@@ -225,17 +381,38 @@ func setAuthzPolicy(
 }
 
 type deployRequest struct {
-	cliVersion       string
-	environmentName  string
-	serviceName      string
-	serviceType      string
-	image            string
-	folderPath       string
-	isPrivateService bool
-	envVars          map[string]string
-	envSecrets       map[string]string
-	resources        config.ResourceRequirements
-	buildTimeEnvVars map[string]string
+	cliVersion         string
+	environmentName    string
+	serviceName        string
+	serviceType        string
+	image              string
+	folderPath         string
+	isPrivateService   bool
+	envVars            map[string]string
+	envSecrets         map[string]string
+	resources          config.ResourceRequirements
+	buildTimeEnvVars   map[string]string
+	includes           []string
+	excludes           []string
+	forceUpload        bool
+	archivePath        string
+	allowSecrets       bool
+	showBuildLogs      bool
+	failureLogsDir     string
+	detach             bool
+	gitSha             string
+	allowedServices    []string
+	disallowedServices []string
+	// code that was already uploaded by an earlier deploy, deployed again instead of bundling the folder
+	uploadKey string
+	// hash of the bundled code, which identifies the code behind the upload key
+	codeHash string
+	// set when the upload key belongs to another environment, describing the deploy it came from.
+	// Should the key be rejected, the code is bundled again from artifactDir, as long as it is unchanged.
+	artifactSource string
+	artifactDir    string
+	// the python service has no Procfile yet, only set for dry runs, which don't ask for one
+	missingProcfile bool
 }
 
 func deploy(
@@ -245,13 +422,175 @@ func deploy(
 	progressType progress.ProgressType,
 ) error {
 	green := progress.SProgressPrint(progressType, color.FgGreen)
-	fmt.Printf("\nGetting deployment ready: \n%sService: %s \n%sEnvironment: %s \n%sProject Directory: %s \n\n",
-		green("↪"), req.serviceName,
-		green("↪"), req.environmentName,
-		green("↪"), req.folderPath,
-	)
+	// json progress keeps stdout for events, everything else is meant for people
+	if progressType != progress.JsonProgress {
+		source := fmt.Sprintf("Project Directory: %s", req.folderPath)
+		if req.folderPath == "" {
+			// redeploying an earlier artifact, there is no directory involved
+			source = fmt.Sprintf("Artifact: %s", req.uploadKey)
+			if req.serviceType == "docker" {
+				source = fmt.Sprintf("Artifact: %s", req.image)
+			}
+		}
+		fmt.Printf("\nGetting deployment ready: \n%sService: %s \n%sEnvironment: %s \n%s%s \n\n",
+			green("↪"), req.serviceName,
+			green("↪"), req.environmentName,
+			green("↪"), source,
+		)
+	}
+
+	output := newServicesProgress(ctx, progressType, []*deployRequest{&req})
+	detach, stopInterrupts := watchInterrupts(output, progressType)
+	result := deployService(ctx, svcClient, &req, progressType, output, 0, detach)
+	stopInterrupts()
+	output.wait()
+	return reportServiceDeploy(ctx, svcClient, &req, progressType, result)
+}
+
+// Reports how the deploy of a single service ended once its progress is no longer shown, and returns the error to exit with.
+// The logs of a failed pipeline are printed unless they were already shown with the build logs.
+func reportServiceDeploy(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	progressType progress.ProgressType,
+	result *serviceDeployResult,
+) error {
+	isJson := progressType == progress.JsonProgress
+	green := progress.SProgressPrint(progressType, color.FgGreen)
+	output := io.Writer(os.Stdout)
+	if isJson {
+		output = os.Stderr
+	}
 
-	deployRequest := svcmgmtv1alpha1.DeployServiceRequest{
+	switch {
+	case result.deployStatus != nil && result.err == nil:
+		if !isJson {
+			printDeployCancelled(output, result.deployStatus)
+		}
+		return nil
+	case result.deployStatus != nil:
+		logOutput := output
+		if result.failureLogsShown {
+			// only the files are left to write
+			logOutput = nil
+		}
+		err := streamPodErrorLogs(ctx, svcClient, req.environmentName, req.serviceName, result.deployStatus, logOutput, req.failureLogsDir)
+		if err != nil {
+			return err
+		}
+		return result.err
+	case result.detached && req.detach:
+		fmt.Fprintf(output, "\nDeploy %s started with pipeline run: %s\n", result.history.Id, result.pipelineRun)
+		printDetachedHelp(output, result.history)
+		return nil
+	case result.detached:
+		printDetachedHelp(output, result.history)
+		return errDeployDetached
+	case result.serviceUrl != "" && !isJson:
+		fmt.Printf("\nService is deployed at: %s\n", green(result.serviceUrl))
+	}
+	return result.err
+}
+
+// Starts the deploy and waits for its first response, so that a rejected upload key is caught up front.
+// If reupload is set, the upload key was reused from a previous deploy. Should the server reject it,
+// the code is uploaded again and the deploy is started once more.
+func startDeploy(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	deployRequest *svcmgmtv1alpha1.DeployServiceRequest,
+	reupload func() (string, error),
+) (svcmgmtv1alpha1.ServiceMgmtService_DeployServiceClient, error) {
+	stream, err := openDeployStream(ctx, svcClient, deployRequest)
+	if err != nil && reupload != nil && isRejectedUpload(err) {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "previous upload was rejected, uploading code again: %s\n", err.Error())
+		}
+		uploadKey, err := reupload()
+		if err != nil {
+			return nil, err
+		}
+		deployRequest.UploadedCodeUri = uploadKey
+		return openDeployStream(ctx, svcClient, deployRequest)
+	}
+	return stream, err
+}
+
+func openDeployStream(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	deployRequest *svcmgmtv1alpha1.DeployServiceRequest,
+) (svcmgmtv1alpha1.ServiceMgmtService_DeployServiceClient, error) {
+	stream, err := svcClient.DeployService(ctx, deployRequest)
+	if err != nil {
+		return nil, err
+	}
+	response, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &peekedDeployStream{ServiceMgmtService_DeployServiceClient: stream, response: response, err: err}, nil
+}
+
+// Returns the response that was already received before reading the rest of the stream
+type peekedDeployStream struct {
+	svcmgmtv1alpha1.ServiceMgmtService_DeployServiceClient
+	response *svcmgmtv1alpha1.DeployServiceResponse
+	err      error
+	consumed bool
+}
+
+func (s *peekedDeployStream) Recv() (*svcmgmtv1alpha1.DeployServiceResponse, error) {
+	if !s.consumed {
+		s.consumed = true
+		return s.response, s.err
+	}
+	return s.ServiceMgmtService_DeployServiceClient.Recv()
+}
+
+// Returns how to upload the code of a deploy from another environment again, or nil if the upload key is the request's own.
+// The code is bundled from the service directory once more, and only uploaded if it is exactly the code that was deployed.
+func getArtifactReupload(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+) func() (string, error) {
+	if req.artifactSource == "" {
+		return nil
+	}
+	return func() (string, error) {
+		if req.artifactDir == "" || req.codeHash == "" {
+			return "", fmt.Errorf("the code of %s isn't available in %s, deploy %s to %s instead", req.artifactSource, req.environmentName, req.serviceName, req.environmentName)
+		}
+		bundleReq := *req
+		bundleReq.folderPath = req.artifactDir
+		fd, summary, err := bundleCode(&bundleReq)
+		if err != nil {
+			return "", err
+		}
+		defer removeBundle(fd)
+		if summary.Hash != req.codeHash {
+			return "", fmt.Errorf(
+				"the code of %s isn't available in %s, and %s has changed since, deploy %s to %s instead",
+				req.artifactSource, req.environmentName, req.artifactDir, req.serviceName, req.environmentName,
+			)
+		}
+		uploadKey, _, err := uploadCodeIfChanged(ctx, svcClient, &bundleReq, fd, summary.Hash, true, nil)
+		return uploadKey, err
+	}
+}
+
+func isRejectedUpload(err error) bool {
+	switch status.Code(err) {
+	case codes.NotFound, codes.InvalidArgument, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func getDeployServiceRequest(req deployRequest) (*svcmgmtv1alpha1.DeployServiceRequest, error) {
+	deployRequest := &svcmgmtv1alpha1.DeployServiceRequest{
 		CliVersion:      req.cliVersion,
 		EnvironmentName: req.environmentName,
 		ServiceName:     req.serviceName,
@@ -274,93 +613,11 @@ func deploy(
 
 	if req.serviceType == "docker" {
 		if req.image == "" {
-			return fmt.Errorf("must provide image if service type is 'docker'")
+			return nil, fmt.Errorf("must provide image if service type is 'docker'")
 		}
 		deployRequest.DockerImage = req.image
-	} else {
-		uploadSpinner := spinner.New(spinner.CharSets[35], 100*time.Millisecond)
-		uploadSpinner.Suffix = "  Bundling and uploading code..."
-		if progressType == progress.TtyProgress {
-			uploadSpinner.Start()
-		} else {
-			fmt.Println("Bundling and uploading code...")
-		}
-		uploadKey, err := bundleAndUploadCode(ctx, svcClient, req.folderPath, req.environmentName, req.serviceName)
-		uploadSpinner.Stop()
-		if err != nil {
-			return err
-		}
-		deployRequest.UploadedCodeUri = uploadKey
-	}
-
-	deployInitSpinner := spinner.New(spinner.CharSets[35], 100*time.Millisecond)
-	deployInitSpinner.Suffix = "  Initiating deployment request"
-	if progressType == progress.TtyProgress {
-		deployInitSpinner.Start()
-	}
-	stream, err := svcClient.DeployService(ctx, &deployRequest)
-	deployInitSpinner.Stop()
-	if err != nil {
-		return err
-	}
-
-	termWidth := progress.GetProgressBarWidth(50)
-	progressContainer := mpb.NewWithContext(
-		ctx,
-		mpb.WithWidth(termWidth),
-	)
-
-	var mainBar *mpb.Bar
-	if progressType != progress.PlainProgress {
-		mainBar = getProgressBar(progressContainer, "Deploying your service...", 100)
 	}
-	printPlainOutput := getPlainOutput()
-
-	for {
-		response, err := stream.Recv()
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			handleMainBar(mainBar, progressType, &ProgressBar{abort: true})
-			return err
-		}
-
-		if response.GetServiceUrl() != "" {
-			handleMainBar(mainBar, progressType, &ProgressBar{currentInt: 100})
-			progressContainer.Wait()
-			fmt.Printf("\nService is deployed at: %s\n", green(response.GetServiceUrl()))
-			break
-		}
-
-		deployStatus := response.GetDeployStatus()
-		if deployStatus == nil {
-			continue
-		}
-
-		if didPipelineFail(deployStatus) {
-			handleMainBar(mainBar, progressType, &ProgressBar{abort: true})
-			progressContainer.Wait()
-			printPlainOutput(deployStatus)
-			if didPipelineGetCancelled(deployStatus) {
-				return nil
-			}
-			err = streamPodErrorLogs(ctx, svcClient, req.environmentName, req.serviceName, deployStatus)
-			if err != nil {
-				return err
-			}
-			return fmt.Errorf("pipeline failed with error")
-		}
-
-		if progressType == progress.PlainProgress {
-			// plain output
-			printPlainOutput(deployStatus)
-		} else {
-			handleMainBar(mainBar, progressType, &ProgressBar{currentInt: int64(getCompletionPercentage(deployStatus))})
-		}
-	}
-
-	return nil
+	return deployRequest, nil
 }
 
 // This doesnt handle the option where a task is gracefully shutdown
@@ -390,30 +647,73 @@ func didPipelineGetCancelled(
 	return false
 }
 
+// Streams the logs of every failed step, each under its own header.
+// Nothing is printed if w is nil, and if logsDir is set each step's log is also written to its own file in it.
 func streamPodErrorLogs(
 	ctx context.Context,
 	client svcmgmtv1alpha1.ServiceMgmtServiceClient,
 	envName string,
 	serviceName string,
 	deployStatus *svcmgmtv1alpha1.DeployStatus,
+	w io.Writer,
+	logsDir string,
 ) error {
 	if deployStatus == nil {
 		return fmt.Errorf("deploystatus was nil")
 	}
+	if w == nil && logsDir == "" {
+		return nil
+	}
 
-	logrequest, err := getLogRequestFromDeployStatus(deployStatus)
-	if err != nil {
-		return err
+	logRequests := getFailedStepLogRequests(deployStatus)
+	if len(logRequests) == 0 {
+		return fmt.Errorf("unable to find task step with error state to print logs for")
 	}
 
-	fmt.Println("====================")
-	fmt.Printf("Printing logs for Task '%s' at Step '%s'\n\n", logrequest.TaskName, logrequest.TaskStep)
-	fmt.Println("====================")
+	// keep going when a step's logs can't be fetched, the other steps may still explain the failure
+	errs := []error{}
+	for _, logRequest := range logRequests {
+		err := streamStepLogs(ctx, client, envName, serviceName, logRequest, w, logsDir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task '%s' step '%s': %w", logRequest.TaskName, logRequest.TaskStep, err))
+		}
+	}
+	if logsDir != "" {
+		fmt.Fprintf(os.Stderr, "Failure logs for %s were written to %s\n", serviceName, filepath.Join(logsDir, serviceName))
+	}
+	return errors.Join(errs...)
+}
+
+func streamStepLogs(
+	ctx context.Context,
+	client svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	envName string,
+	serviceName string,
+	logRequest *svcmgmtv1alpha1.PipelineRunLogRequest,
+	w io.Writer,
+	logsDir string,
+) error {
+	output := io.Discard
+	if w != nil {
+		fmt.Fprintln(w, "====================")
+		fmt.Fprintf(w, "Printing logs for Task '%s' at Step '%s'\n\n", logRequest.TaskName, logRequest.TaskStep)
+		fmt.Fprintln(w, "====================")
+		defer fmt.Fprintln(w, "====================")
+		output = w
+	}
+	if logsDir != "" {
+		fd, err := createFailureLogFile(logsDir, serviceName, logRequest)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		output = io.MultiWriter(output, fd)
+	}
 
 	stream, err := client.GetDeployLogs(ctx, &svcmgmtv1alpha1.GetDeployLogsRequest{
 		EnvironmentName: envName,
 		ServiceName:     serviceName,
-		LogRequest:      logrequest,
+		LogRequest:      logRequest,
 	})
 	if err != nil {
 		return err
@@ -422,41 +722,51 @@ func streamPodErrorLogs(
 		response, err := stream.Recv()
 		if err != nil {
 			if err == io.EOF {
-				fmt.Println("====================")
 				return nil
 			}
 			return err
 		}
-		fmt.Println(response.LogLine)
+		fmt.Fprintln(output, response.LogLine)
 	}
 }
 
-func getLogRequestFromDeployStatus(
-	deployStatus *svcmgmtv1alpha1.DeployStatus,
-) (*svcmgmtv1alpha1.PipelineRunLogRequest, error) {
-	if deployStatus == nil {
-		return nil, fmt.Errorf("deploy status was nil")
+// Creates <logsDir>/<service>/<task>/<step>.log, so that logs from several services and deploys can share a directory
+func createFailureLogFile(
+	logsDir string,
+	serviceName string,
+	logRequest *svcmgmtv1alpha1.PipelineRunLogRequest,
+) (*os.File, error) {
+	dir := filepath.Join(logsDir, serviceName, logRequest.TaskName)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
 	}
+	return os.Create(filepath.Join(dir, fmt.Sprintf("%s.log", logRequest.TaskStep)))
+}
 
-	taskStatus := getLastTaskStatus(deployStatus.DeployTaskStatus)
-	if taskStatus == nil {
-		return nil, fmt.Errorf("unable to find task status with error state to print logs for")
+// Returns a log request for every step that terminated with an error, in task order.
+// Tasks can run in parallel, so more than one of them may have failed.
+func getFailedStepLogRequests(
+	deployStatus *svcmgmtv1alpha1.DeployStatus,
+) []*svcmgmtv1alpha1.PipelineRunLogRequest {
+	logRequests := []*svcmgmtv1alpha1.PipelineRunLogRequest{}
+	if deployStatus == nil {
+		return logRequests
 	}
-
-	for _, step := range taskStatus.Steps {
-		terminatedState := step.GetTerminated()
-		if terminatedState == nil {
-			continue
-		}
-		if terminatedState.Reason == "Error" {
-			return &svcmgmtv1alpha1.PipelineRunLogRequest{
+	for _, taskStatus := range deployStatus.DeployTaskStatus {
+		for _, step := range taskStatus.Steps {
+			terminatedState := step.GetTerminated()
+			if terminatedState == nil || terminatedState.Reason != "Error" {
+				continue
+			}
+			logRequests = append(logRequests, &svcmgmtv1alpha1.PipelineRunLogRequest{
 				PipelineRun: deployStatus.PipelineRun,
 				TaskName:    taskStatus.Name,
 				TaskStep:    step.Name,
-			}, nil
+			})
 		}
 	}
-	return nil, fmt.Errorf("unable to find task step with error state to print logs for")
+	return logRequests
 }
 
 func getLastTaskStatus(statuses []*svcmgmtv1alpha1.DeployTaskStatus) *svcmgmtv1alpha1.DeployTaskStatus {
@@ -491,85 +801,29 @@ func getLastTaskStatus(statuses []*svcmgmtv1alpha1.DeployTaskStatus) *svcmgmtv1a
 	return lastTaskStatus
 }
 
-func handleMainBar(bar *mpb.Bar, progressType progress.ProgressType, barSettings *ProgressBar) {
-	if progressType == progress.PlainProgress {
-		return
-	}
-	if barSettings.abort {
-		bar.Abort(true)
-		return
-	}
-	bar.SetCurrent(barSettings.currentInt)
-}
-
 func didPipelineFail(deployStatus *svcmgmtv1alpha1.DeployStatus) bool {
 	return deployStatus != nil && deployStatus.Succeeded != nil && deployStatus.Succeeded.Status == "False"
 }
 
-func getCompletionPercentage(deployStatus *svcmgmtv1alpha1.DeployStatus) float64 {
-	maxComplete := 0.
-
-	numStages := float64(len(deployStatus.DeployTaskStatus))
-
-	for tsIdx, taskStatus := range deployStatus.DeployTaskStatus {
-		floorVal := float64(tsIdx) / numStages
-		ceilVal := float64((tsIdx + 1)) / numStages
-		if taskStatus.StartTime == nil {
-			continue
-		}
-
-		numSteps := len(taskStatus.Steps)
-		for stepIdx, step := range taskStatus.Steps {
-			currstep := stepIdx + 1
-			subCeil := getPercentageComplete(ceilVal, floorVal, currstep, numSteps)
-			totalSubSteps := 3 // waiting, running, terminated
-
-			if step.GetWaiting() != nil {
-				maxComplete = getPercentageComplete(subCeil, floorVal, 1, totalSubSteps)
-			}
-			if step.GetRunning() != nil {
-				maxComplete = getPercentageComplete(subCeil, floorVal, 2, totalSubSteps)
-			}
-			if step.GetTerminated() != nil {
-				// equivalent to: getPercentageComplete(subCeil, floorVal, 3, 3)
-				maxComplete = subCeil
-			}
-		}
-	}
-	return maxComplete * 100
-}
-
-func getPercentageComplete(ceilVal float64, floorVal float64, currStep int, numSteps int) float64 {
-	return ((ceilVal-floorVal)*(float64(currStep)/float64(numSteps)) + floorVal)
-}
-
-func getPlainOutput() func(deployStatus *svcmgmtv1alpha1.DeployStatus) {
-	printedTasks := map[string]int{}
-
-	return func(deployStatus *svcmgmtv1alpha1.DeployStatus) {
-		plainOutput(deployStatus, printedTasks)
-	}
-}
-
-func plainOutput(deployUpdate *svcmgmtv1alpha1.DeployStatus, printedTasks map[string]int) {
+func plainOutput(w io.Writer, deployUpdate *svcmgmtv1alpha1.DeployStatus, printedTasks map[string]int) {
 	for _, taskStatus := range deployUpdate.DeployTaskStatus {
 		if shouldPrint(taskStatus, printedTasks) {
-			fmt.Println("====================")
+			fmt.Fprintln(w, "====================")
 			if deployUpdate.Succeeded != nil {
-				fmt.Println("Status Message", deployUpdate.Succeeded.Message)
+				fmt.Fprintln(w, "Status Message", deployUpdate.Succeeded.Message)
 			}
-			fmt.Printf("    Task Name: '%s'\n    Start Time: '%s'\n    Complete Time: '%s'\n    Num Steps: '%d'\n", taskStatus.Name, taskStatus.GetStartTime(), taskStatus.GetCompletionTime(), len(taskStatus.Steps))
+			fmt.Fprintf(w, "    Task Name: '%s'\n    Start Time: '%s'\n    Complete Time: '%s'\n    Num Steps: '%d'\n", taskStatus.Name, taskStatus.GetStartTime(), taskStatus.GetCompletionTime(), len(taskStatus.Steps))
 			for _, step := range taskStatus.Steps {
-				fmt.Printf("        Task Step: '%s'\n", step.Name)
+				fmt.Fprintf(w, "        Task Step: '%s'\n", step.Name)
 				waiting := step.GetWaiting()
 				terminating := step.GetTerminated()
 				running := step.GetRunning()
 				if waiting != nil {
-					fmt.Printf("            Waiting: Reason: '%s' Message: '%s'\n", waiting.Reason, waiting.Message)
+					fmt.Fprintf(w, "            Waiting: Reason: '%s' Message: '%s'\n", waiting.Reason, waiting.Message)
 				} else if running != nil {
-					fmt.Printf("            Running: '%s'\n", running.StartedAt)
+					fmt.Fprintf(w, "            Running: '%s'\n", running.StartedAt)
 				} else if terminating != nil {
-					fmt.Printf("            Terminated: Reason: '%s' Message: '%s' Started At: '%s' Finished At: '%s'\n", terminating.Reason, terminating.Message, terminating.StartedAt, terminating.FinishedAt)
+					fmt.Fprintf(w, "            Terminated: Reason: '%s' Message: '%s' Started At: '%s' Finished At: '%s'\n", terminating.Reason, terminating.Message, terminating.StartedAt, terminating.FinishedAt)
 				}
 			}
 		}
@@ -596,90 +850,221 @@ func shouldPrint(taskStatus *svcmgmtv1alpha1.DeployTaskStatus, printedTasks map[
 	return false
 }
 
-func bundleAndUploadCode(
-	ctx context.Context,
-	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
-	folderPath string,
-	environmentName string,
-	serviceName string,
-) (string, error) {
-	fd, err := os.CreateTemp("", "nucleus-cli-")
+const (
+	bundleTempPrefix = "nucleus-cli-"
+)
+
+// Returns the code bundle to upload for the service, along with a summary of its contents.
+// This is the prebuilt archive if one was provided, otherwise the service directory is bundled into a temp file.
+// The bundle must be released with removeBundle once it is no longer needed.
+func getCodeBundle(req *deployRequest) (*os.File, *archive.Summary, error) {
+	if req.archivePath == "" {
+		return bundleCode(req)
+	}
+
+	fd, err := os.Open(req.archivePath)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
+	summary, err := archive.ReadSummary(fd)
+	if err != nil {
+		fd.Close()
+		return nil, nil, fmt.Errorf("%s: %w", req.archivePath, err)
+	}
+	return fd, summary, nil
+}
 
-	if verbose {
-		fmt.Printf("archiving directory into temp file: %s", fd.Name())
+// Scans the code bundle for files and values that look like secrets before it is uploaded.
+// Findings block the deploy unless they are allowlisted or secrets are explicitly allowed.
+func checkCodeBundleSecrets(req *deployRequest, fd *os.File) error {
+	findings, err := scanCodeBundle(req.folderPath, fd)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		return nil
 	}
 
-	gitignorePath := filepath.Join(folderPath, ".gitignore")
-	_, err = os.Stat(gitignorePath)
-	if errors.Is(err, os.ErrNotExist) {
-		err = ga.GzipCompress(folderPath, fd, ga.IgnoreDotGit())
-	} else {
-		err = ga.GzipCompress(folderPath, fd, ga.ArchiveGitRepo())
+	lines := []string{}
+	for _, finding := range findings {
+		lines = append(lines, fmt.Sprintf("  %s", finding.String()))
 	}
-	if err != nil {
-		return "", err
+	if req.allowSecrets {
+		fmt.Fprintf(os.Stderr, "Warning: uploading code for service %s that may contain secrets:\n%s\n", req.serviceName, strings.Join(lines, "\n"))
+		return nil
 	}
+	return fmt.Errorf(
+		"code for service %s may contain secrets:\n%s\nremove them, leave them out with %s, allow them in %s, or rerun with --allow-secrets",
+		req.serviceName, strings.Join(lines, "\n"), archive.NucleusIgnoreFileName, secretscan.AllowlistFileName,
+	)
+}
 
-	// flush buffer to disk
-	err = fd.Sync()
+// Scans the gzipped code bundle, honoring the allowlist in the service directory
+func scanCodeBundle(folderPath string, fd *os.File) ([]*secretscan.Finding, error) {
+	allowlist, err := secretscan.LoadAllowlist(folderPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fd.Stat()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	findings, err := secretscan.ScanArchive(io.NewSectionReader(fd, 0, info.Size()), allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan code bundle: %w", err)
 	}
+	return findings, nil
+}
 
-	// set file reader back to the beginning of the file
-	_, err = fd.Seek(0, 0)
+// Archives the service directory into a temp file
+func bundleCode(req *deployRequest) (*os.File, *archive.Summary, error) {
+	fd, err := os.CreateTemp("", bundleTempPrefix)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
-	signedResponse, err := svcClient.GetServiceUploadUrl(ctx, &svcmgmtv1alpha1.GetServiceUploadUrlRequest{
-		EnvironmentName: environmentName,
-		ServiceName:     serviceName,
-	})
+	if verbose {
+		fmt.Fprintf(os.Stderr, "archiving directory into temp file: %s\n", fd.Name())
+	}
+
+	filters := getArchiveFilters(req.folderPath, req.serviceName, req.includes, req.excludes)
+	summary, err := archive.GzipDirectory(req.folderPath, fd, filters...)
 	if err != nil {
-		return "", err
+		removeBundle(fd)
+		return nil, nil, err
 	}
+	printArchiveWarnings(req.serviceName, summary)
 
-	err = uploadArchive(signedResponse.Url, fd)
+	// flush buffer to disk
+	err = fd.Sync()
 	if err != nil {
-		return "", err
+		removeBundle(fd)
+		return nil, nil, err
 	}
-	return signedResponse.UploadKey, nil
+	return fd, summary, nil
 }
 
-func uploadArchive(signedURL string, r io.Reader) error {
-	httpClient := &http.Client{
-		Timeout: 120 * time.Second,
+// Prints the symlinks that were left out of the service's code bundle
+func printArchiveWarnings(serviceName string, summary *archive.Summary) {
+	for _, warning := range summary.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: service %s: skipping symlink %s\n", serviceName, warning.String())
 	}
+}
 
-	// TODO: Figure out how to send this as a stream instead of reading into memory.
-	// S3 seems to not like when we send this as a stream
-	// https://stackoverflow.com/questions/67896779/streaming-uploading-to-s3-using-presigned-url
-	// https://github.com/aws/aws-sdk-js/issues/1603
-	buf := &bytes.Buffer{}
-	nRead, err := io.Copy(buf, r)
-	if err != nil {
-		return err
+// Closes the bundle, and removes it if it is a temp file
+func removeBundle(fd *os.File) {
+	fd.Close()
+	if filepath.Dir(fd.Name()) == filepath.Clean(os.TempDir()) && strings.HasPrefix(filepath.Base(fd.Name()), bundleTempPrefix) {
+		os.Remove(fd.Name())
 	}
+}
 
-	req, err := http.NewRequest(http.MethodPut, signedURL, buf)
-	if err != nil {
-		return err
+// Returns the upload key of the bundled code, and whether it was reused from a previous upload.
+// Code with the same hash as the last upload of the service to the environment is not uploaded again unless forced.
+func uploadCodeIfChanged(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	fd *os.File,
+	hash string,
+	force bool,
+	onProgress upload.ProgressFunc,
+) (string, bool, error) {
+	apiEnv := string(clienv.GetEnv())
+	if !force {
+		if uploadKey, ok := config.GetCachedUploadKey(apiEnv, req.environmentName, req.serviceName, hash); ok {
+			return uploadKey, true, nil
+		}
 	}
 
-	req.Header.Set("content-type", "application/gzip")
-	req.Header.Set("content-length", strconv.FormatInt(nRead, 10))
-	rsp, err := httpClient.Do(req)
+	uploadKey, err := uploadCode(ctx, svcClient, req, fd, onProgress)
 	if err != nil {
-		return err
-	} else if rsp.StatusCode != http.StatusOK {
-		fmt.Print(rsp)
-		return fmt.Errorf("upload didn't work: %s", rsp.Status)
+		return "", false, err
+	}
+	err = config.SetCachedUploadKey(apiEnv, req.environmentName, req.serviceName, hash, uploadKey)
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "unable to cache upload key: %s\n", err.Error())
+	}
+	return uploadKey, false, nil
+}
+
+// Uploads the bundled code and returns the key to deploy it with.
+// Failed uploads are retried, and a new signed url is requested if the current one expires.
+func uploadCode(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	fd *os.File,
+	onProgress upload.ProgressFunc,
+) (string, error) {
+	getSignedUrl := func(ctx context.Context) (*upload.SignedUrl, error) {
+		signedResponse, err := svcClient.GetServiceUploadUrl(ctx, &svcmgmtv1alpha1.GetServiceUploadUrlRequest{
+			EnvironmentName: req.environmentName,
+			ServiceName:     req.serviceName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &upload.SignedUrl{Url: signedResponse.Url, UploadKey: signedResponse.UploadKey}, nil
+	}
+
+	opts := upload.DefaultRetryOptions()
+	if verbose {
+		opts.OnRetry = func(attempt int, err error, wait time.Duration) {
+			fmt.Fprintf(os.Stderr, "upload attempt %d for %s failed, retrying in %s: %s\n", attempt, req.serviceName, wait.Round(time.Millisecond), err.Error())
+		}
 	}
+	return upload.UploadFileWithRetry(ctx, getSignedUrl, fd, onProgress, opts)
+}
 
+// Returns the filters that decide which files of the directory are bundled:
+// .git and the default output of nucleus bundle are always left out, along with anything matched by the .gitignore and .nucleusignore files in the tree
+// (.nucleusignore taking priority) and the project.toml include/exclude globs.
+func getArchiveFilters(folderPath string, serviceName string, includes []string, excludes []string) []archive.Filter {
+	filters := []archive.Filter{
+		// a bundle that nucleus bundle left in the service directory is never bundled again
+		archive.IgnorePatterns("**/.git", archive.GitIgnoreFileName, archive.NucleusIgnoreFileName, secretscan.AllowlistFileName, "/"+getDefaultBundleName(serviceName)),
+		archive.IgnoreFiles(folderPath, archive.GitIgnoreFileName, archive.NucleusIgnoreFileName),
+	}
+	if len(includes) > 0 {
+		filters = append(filters, archive.IncludePatterns(includes...))
+	}
+	if len(excludes) > 0 {
+		filters = append(filters, archive.IgnorePatterns(excludes...))
+	}
+	return filters
+}
+
+// Returns the service's project.toml, or nil if it doesn't have one
+func getServiceProjectFile(directoryName string) (*projecttoml.ProjectToml, error) {
+	projectTomlPath := filepath.Join(directoryName, projecttoml.ProjectTomlPath)
+	if !projecttoml.DoesProjectFileExist(projectTomlPath) {
+		return nil, nil
+	}
+	return projecttoml.GetProjectFile(projectTomlPath)
+}
+
+// Prints every file that would be left out of the code bundle of each service
+func printIgnoredFiles(reqs []*deployRequest) error {
+	for _, req := range reqs {
+		if req.serviceType == "docker" {
+			fmt.Printf("Service %s uses a docker image, no code is bundled\n", req.serviceName)
+			continue
+		}
+		filters := getArchiveFilters(req.folderPath, req.serviceName, req.includes, req.excludes)
+		ignored, err := archive.ListIgnored(req.folderPath, filters...)
+		if err != nil {
+			return err
+		}
+		if len(ignored) == 0 {
+			fmt.Printf("No files are ignored for service %s\n", req.serviceName)
+			continue
+		}
+		fmt.Printf("Files ignored for service %s (%s):\n", req.serviceName, req.folderPath)
+		for _, path := range ignored {
+			fmt.Printf("  %s\n", path)
+		}
+	}
 	return nil
 }
 
@@ -687,32 +1072,18 @@ func init() {
 	rootCmd.AddCommand(deployCmd)
 
 	deployCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
+	deployCmd.Flags().Bool("all", false, "deploy every service defined in the nucleus manifest")
+	deployCmd.Flags().StringSliceP("service", "s", []string{}, "comma separated list of services from the nucleus manifest to deploy")
+	deployCmd.Flags().Int("concurrency", 3, "max number of services to deploy at once")
+	deployCmd.Flags().Bool("local-env", false, "allow vars to reference variables from the local environment")
+	deployCmd.Flags().String("archive", "", "deploy a code bundle built with 'nucleus bundle' instead of bundling the service directory")
+	deployCmd.Flags().Bool("allow-secrets", false, "upload the code even if it looks like it contains secrets")
+	deployCmd.Flags().Bool("force-upload", false, "upload the code even if it is unchanged since the last deploy")
+	deployCmd.Flags().Bool("show-build-logs", false, "stream the logs of every pipeline step while the service is deployed")
+	deployCmd.Flags().String("failure-logs-dir", "", "write the logs of every failed pipeline step to its own file in this directory")
+	deployCmd.Flags().Bool("detach", false, "return as soon as nucleus has accepted the deploy instead of waiting for it to finish")
+	deployCmd.Flags().Bool("dry-run", false, "print everything that would be sent to nucleus, including a summary of the code bundle, without deploying")
+	deployCmd.Flags().StringP("output", "o", "", "output format for --dry-run (json)")
+	deployCmd.Flags().Bool("show-ignored", false, "list the files that would be left out of the code bundle and exit without deploying")
 	progress.AttachProgressFlag(deployCmd)
 }
-
-func getProgressBar(progress *mpb.Progress, name string, total int) *mpb.Bar {
-	spinnerStyle := []string{
-		color.GreenString("⠋"),
-		color.GreenString("⠙"),
-		color.GreenString("⠹"),
-		color.GreenString("⠸"),
-		color.GreenString("⠼"),
-		color.GreenString("⠴"),
-		color.GreenString("⠦"),
-		color.GreenString("⠧"),
-		color.GreenString("⠇"),
-		color.GreenString("⠏"),
-	}
-	return progress.New(int64(total),
-		// BarFillerBuilder with custom style
-		mpb.BarStyle().Lbound("╢").Filler("▌").Tip("▌").Padding("░").Rbound("╟"),
-		mpb.PrependDecorators(
-			decor.Name(name, decor.WC{W: len(name) + 1, C: decor.DidentRight}),
-			decor.OnComplete(
-				decor.Spinner(spinnerStyle),
-				"",
-			),
-		),
-		mpb.AppendDecorators(decor.Percentage()),
-	)
-}
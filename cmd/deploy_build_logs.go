@@ -0,0 +1,186 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+
+	"github.com/nucleuscloud/cli/internal/progress"
+)
+
+// Receives a single log line of a pipeline step
+type buildLogSink func(task string, step string, line string)
+
+// Tails the logs of every pipeline step once it starts running.
+// A nil *buildLogTailer is valid and does nothing, so callers don't have to check if build logs were requested.
+type buildLogTailer struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	svcClient       svcmgmtv1alpha1.ServiceMgmtServiceClient
+	environmentName string
+	serviceName     string
+	sink            buildLogSink
+
+	mu sync.Mutex
+	// every step that has been tailed, keyed by task/step
+	tailed map[string]struct{}
+	wg     sync.WaitGroup
+}
+
+func newBuildLogTailer(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	sink buildLogSink,
+) *buildLogTailer {
+	ctx, cancel := context.WithCancel(ctx)
+	return &buildLogTailer{
+		ctx:             ctx,
+		cancel:          cancel,
+		svcClient:       svcClient,
+		environmentName: req.environmentName,
+		serviceName:     req.serviceName,
+		sink:            sink,
+		tailed:          map[string]struct{}{},
+	}
+}
+
+// Starts tailing every step that is running or has terminated and isn't being tailed yet.
+// Steps that finished between two deploy statuses are still caught, their logs are printed in full.
+func (t *buildLogTailer) update(deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if t == nil || deployStatus == nil {
+		return
+	}
+	for _, taskStatus := range deployStatus.DeployTaskStatus {
+		for _, step := range taskStatus.Steps {
+			if step.GetRunning() == nil && step.GetTerminated() == nil {
+				continue
+			}
+			if !t.markTailed(taskStatus.Name, step.Name) {
+				continue
+			}
+			t.wg.Add(1)
+			go func(pipelineRun string, taskName string, stepName string) {
+				defer t.wg.Done()
+				err := t.tail(pipelineRun, taskName, stepName)
+				if err != nil && t.ctx.Err() == nil {
+					t.sink(taskName, stepName, fmt.Sprintf("unable to stream logs: %s", err.Error()))
+				}
+			}(deployStatus.PipelineRun, taskStatus.Name, step.Name)
+		}
+	}
+}
+
+// Records that the step is being tailed, returns false if it already was
+func (t *buildLogTailer) markTailed(taskName string, stepName string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := taskName + "/" + stepName
+	if _, ok := t.tailed[key]; ok {
+		return false
+	}
+	t.tailed[key] = struct{}{}
+	return true
+}
+
+// Reports whether the logs of every step that failed the pipeline have already been shown
+func (t *buildLogTailer) hasTailedFailedSteps(deployStatus *svcmgmtv1alpha1.DeployStatus) bool {
+	if t == nil {
+		return false
+	}
+	logRequests := getFailedStepLogRequests(deployStatus)
+	if len(logRequests) == 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, logRequest := range logRequests {
+		if _, ok := t.tailed[logRequest.TaskName+"/"+logRequest.TaskStep]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *buildLogTailer) tail(pipelineRun string, taskName string, stepName string) error {
+	stream, err := t.svcClient.GetDeployLogs(t.ctx, &svcmgmtv1alpha1.GetDeployLogsRequest{
+		EnvironmentName: t.environmentName,
+		ServiceName:     t.serviceName,
+		LogRequest: &svcmgmtv1alpha1.PipelineRunLogRequest{
+			PipelineRun: pipelineRun,
+			TaskName:    taskName,
+			TaskStep:    stepName,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		t.sink(taskName, stepName, response.LogLine)
+	}
+}
+
+// Waits for the logs of every tailed step to finish, which they do once the pipeline is done
+func (t *buildLogTailer) wait() {
+	if t == nil {
+		return
+	}
+	t.wg.Wait()
+	t.cancel()
+}
+
+// Stops tailing right away, for when the deploy ends before the pipeline does
+func (t *buildLogTailer) stop() {
+	if t == nil {
+		return
+	}
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Returns where build log lines go for the progress type.
+// Lines are printed above the progress bars in tty mode, and become log events in json mode.
+// The prefix is prepended to every line, which is used to tell services apart when several are deployed at once.
+func getBuildLogSink(
+	progressType progress.ProgressType,
+	output io.Writer,
+	events *deployEvents,
+	prefix string,
+) buildLogSink {
+	if progressType == progress.JsonProgress {
+		return events.log
+	}
+	cyan := progress.SProgressPrint(progressType, color.FgCyan)
+	var mu sync.Mutex
+	return func(task string, step string, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(output, "%s %s\n", cyan(fmt.Sprintf("[%s%s/%s]", prefix, task, step)), line)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nucleuscloud/cli/internal/archive"
+)
+
+const (
+	dryRunLargestFiles = 5
+)
+
+type dryRunOutput struct {
+	Services []*dryRunService `json:"services"`
+}
+
+type dryRunService struct {
+	EnvironmentName  string            `json:"environmentName"`
+	ServiceName      string            `json:"serviceName"`
+	ServiceType      string            `json:"serviceType"`
+	IsPrivate        bool              `json:"isPrivate"`
+	DockerImage      string            `json:"dockerImage,omitempty"`
+	EnvVars          map[string]string `json:"envVars"`
+	SecretKeys       []string          `json:"secretKeys"`
+	Resources        *dryRunResources  `json:"resources"`
+	BuildtimeEnvVars map[string]string `json:"buildtimeEnvVars"`
+	MtlsPolicy       *dryRunMtlsPolicy `json:"mtlsPolicy"`
+	Bundle           *dryRunBundle     `json:"bundle,omitempty"`
+	// the deploy asks for the entrypoint of the web server and writes a Procfile with it
+	MissingProcfile bool `json:"missingProcfile,omitempty"`
+}
+
+type dryRunResources struct {
+	Minimum *dryRunResourceList `json:"minimum"`
+	Maximum *dryRunResourceList `json:"maximum"`
+}
+
+type dryRunResourceList struct {
+	Cpu    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+type dryRunMtlsPolicy struct {
+	AllowedServices    []string `json:"allowedServices"`
+	DisallowedServices []string `json:"disallowedServices"`
+}
+
+type dryRunBundle struct {
+	Archive          string          `json:"archive,omitempty"`
+	Hash             string          `json:"hash"`
+	Files            []*archive.File `json:"files"`
+	TotalSize        int64           `json:"totalSize"`
+	CompressedSize   int64           `json:"compressedSize"`
+	PotentialSecrets []string        `json:"potentialSecrets"`
+}
+
+// Resolves everything a deploy of each service would send, without talking to nucleus.
+// The code is still bundled so that its contents can be reviewed.
+func printDryRun(reqs []*deployRequest, output string) error {
+	result := &dryRunOutput{Services: []*dryRunService{}}
+	for _, req := range reqs {
+		svc, err := getDryRunService(req)
+		if err != nil {
+			return err
+		}
+		result.Services = append(result.Services, svc)
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	for idx, svc := range result.Services {
+		if idx > 0 {
+			fmt.Println()
+		}
+		printDryRunService(svc)
+	}
+	return nil
+}
+
+func getDryRunService(req *deployRequest) (*dryRunService, error) {
+	deployRequest, err := getDeployServiceRequest(*req)
+	if err != nil {
+		return nil, err
+	}
+
+	// secrets are encrypted, only their keys are worth reviewing
+	secretKeys := []string{}
+	for key := range deployRequest.Secrets {
+		secretKeys = append(secretKeys, key)
+	}
+	sort.Strings(secretKeys)
+
+	svc := &dryRunService{
+		EnvironmentName: deployRequest.EnvironmentName,
+		ServiceName:     deployRequest.ServiceName,
+		ServiceType:     deployRequest.ServiceType,
+		IsPrivate:       deployRequest.IsPrivate,
+		DockerImage:     deployRequest.DockerImage,
+		EnvVars:         nonNilMap(deployRequest.EnvVars),
+		SecretKeys:      secretKeys,
+		Resources: &dryRunResources{
+			Minimum: &dryRunResourceList{
+				Cpu:    deployRequest.Resources.Minimum.Cpu,
+				Memory: deployRequest.Resources.Minimum.Memory,
+			},
+			Maximum: &dryRunResourceList{
+				Cpu:    deployRequest.Resources.Maximum.Cpu,
+				Memory: deployRequest.Resources.Maximum.Memory,
+			},
+		},
+		BuildtimeEnvVars: nonNilMap(deployRequest.BuildtimeEnvVars),
+		MtlsPolicy: &dryRunMtlsPolicy{
+			AllowedServices:    nonNilSlice(req.allowedServices),
+			DisallowedServices: nonNilSlice(req.disallowedServices),
+		},
+		MissingProcfile: req.missingProcfile,
+	}
+	if req.serviceType == "docker" {
+		return svc, nil
+	}
+
+	fd, summary, err := getCodeBundle(req)
+	if err != nil {
+		return nil, err
+	}
+	defer removeBundle(fd)
+	findings, err := scanCodeBundle(req.folderPath, fd)
+	if err != nil {
+		return nil, err
+	}
+	potentialSecrets := []string{}
+	for _, finding := range findings {
+		potentialSecrets = append(potentialSecrets, finding.String())
+	}
+	svc.Bundle = &dryRunBundle{
+		Archive:          req.archivePath,
+		Hash:             summary.Hash,
+		Files:            summary.Files,
+		TotalSize:        summary.TotalSize,
+		CompressedSize:   summary.CompressedSize,
+		PotentialSecrets: potentialSecrets,
+	}
+	return svc, nil
+}
+
+func printDryRunService(svc *dryRunService) {
+	fmt.Printf("Service: %s\n", svc.ServiceName)
+	fmt.Printf("  Environment: %s\n", svc.EnvironmentName)
+	fmt.Printf("  Service type: %s\n", svc.ServiceType)
+	fmt.Printf("  Private: %t\n", svc.IsPrivate)
+	if svc.DockerImage != "" {
+		fmt.Printf("  Docker image: %s\n", svc.DockerImage)
+	}
+	fmt.Printf("  Resources: minimum cpu=%s memory=%s, maximum cpu=%s memory=%s\n",
+		svc.Resources.Minimum.Cpu, svc.Resources.Minimum.Memory,
+		svc.Resources.Maximum.Cpu, svc.Resources.Maximum.Memory,
+	)
+	printDryRunMap("Vars", svc.EnvVars)
+	printDryRunList("Secrets", svc.SecretKeys)
+	printDryRunMap("Build env vars", svc.BuildtimeEnvVars)
+	printDryRunList("Allowed services", svc.MtlsPolicy.AllowedServices)
+	printDryRunList("Disallowed services", svc.MtlsPolicy.DisallowedServices)
+	if svc.MissingProcfile {
+		fmt.Println("  Procfile: missing, deploy will ask for the entrypoint of your web server and write one")
+	}
+
+	if svc.Bundle == nil {
+		return
+	}
+	if svc.Bundle.Archive != "" {
+		fmt.Printf("  Code bundle: %s\n", svc.Bundle.Archive)
+	} else {
+		fmt.Println("  Code bundle:")
+	}
+	fmt.Printf("    Hash: %s\n", svc.Bundle.Hash)
+	fmt.Printf("    Files: %d (%s, %s compressed)\n", len(svc.Bundle.Files), formatBytes(svc.Bundle.TotalSize), formatBytes(svc.Bundle.CompressedSize))
+	for _, file := range getLargestFiles(svc.Bundle.Files, dryRunLargestFiles) {
+		fmt.Printf("      %-10s %s\n", formatBytes(file.Size), file.Name)
+	}
+	printDryRunList("Potential secrets", svc.Bundle.PotentialSecrets)
+}
+
+func printDryRunMap(title string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{}
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%s", key, formatSingleLine(values[key])))
+	}
+	printDryRunList(title, lines)
+}
+
+// Quotes multiline values to keep them on a single line
+func formatSingleLine(value string) string {
+	if strings.ContainsAny(value, "\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func printDryRunList(title string, values []string) {
+	if len(values) == 0 {
+		fmt.Printf("  %s: none\n", title)
+		return
+	}
+	fmt.Printf("  %s:\n    %s\n", title, strings.Join(values, "\n    "))
+}
+
+func nonNilMap(values map[string]string) map[string]string {
+	if values == nil {
+		return map[string]string{}
+	}
+	return values
+}
+
+func nonNilSlice(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
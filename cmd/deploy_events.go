@@ -0,0 +1,232 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+
+	"github.com/nucleuscloud/cli/internal/progress"
+)
+
+// Event names emitted by json progress. These are part of the CLI's interface, don't rename them.
+const (
+	deployStartedEvent   = "deploy_started"
+	uploadStartedEvent   = "upload_started"
+	uploadFinishedEvent  = "upload_finished"
+	taskEvent            = "task"
+	stepEvent            = "step"
+	serviceDeployedEvent = "service_deployed"
+	deployFailedEvent    = "deploy_failed"
+	deployCancelledEvent = "deploy_cancelled"
+	deployDetachedEvent  = "deploy_detached"
+	policyFailedEvent    = "policy_failed"
+	logEvent             = "log"
+)
+
+// A single line of json progress output
+type deployEvent struct {
+	Time        string `json:"time"`
+	Event       string `json:"event"`
+	Environment string `json:"environment"`
+	Service     string `json:"service"`
+	Task        string `json:"task,omitempty"`
+	Step        string `json:"step,omitempty"`
+	State       string `json:"state,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Message     string `json:"message,omitempty"`
+	ExitCode    *int32 `json:"exitCode,omitempty"`
+	Bytes       int64  `json:"bytes,omitempty"`
+	Cached      bool   `json:"cached,omitempty"`
+	PipelineRun string `json:"pipelineRun,omitempty"`
+	Url         string `json:"url,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+var (
+	// services deployed at the same time share stdout, so whole lines must be written at once
+	eventEncoderMu sync.Mutex
+	eventEncoder   = json.NewEncoder(os.Stdout)
+)
+
+// Emits NDJSON events for the deploy of a single service.
+// A nil *deployEvents is valid and emits nothing, so callers don't have to check the progress type.
+type deployEvents struct {
+	environmentName string
+	serviceName     string
+	// last reported state of every task and step, to only emit transitions
+	states   map[string]string
+	finished bool
+}
+
+// Returns nil unless the progress type is json
+func newDeployEvents(progressType progress.ProgressType, req *deployRequest) *deployEvents {
+	if progressType != progress.JsonProgress {
+		return nil
+	}
+	return &deployEvents{
+		environmentName: req.environmentName,
+		serviceName:     req.serviceName,
+		states:          map[string]string{},
+	}
+}
+
+func (e *deployEvents) emit(event *deployEvent) {
+	if e == nil {
+		return
+	}
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	event.Environment = e.environmentName
+	event.Service = e.serviceName
+
+	eventEncoderMu.Lock()
+	defer eventEncoderMu.Unlock()
+	// stdout going away is not a reason to fail the deploy
+	_ = eventEncoder.Encode(event)
+}
+
+func (e *deployEvents) started() {
+	e.emit(&deployEvent{Event: deployStartedEvent})
+}
+
+func (e *deployEvents) uploadStarted(size int64) {
+	e.emit(&deployEvent{Event: uploadStartedEvent, Bytes: size})
+}
+
+func (e *deployEvents) uploadFinished(size int64, isCached bool) {
+	e.emit(&deployEvent{Event: uploadFinishedEvent, Bytes: size, Cached: isCached})
+}
+
+func (e *deployEvents) log(task string, step string, line string) {
+	e.emit(&deployEvent{Event: logEvent, Task: task, Step: step, Message: line})
+}
+
+func (e *deployEvents) deployed(url string) {
+	e.emit(&deployEvent{Event: serviceDeployedEvent, Url: url})
+	e.finished = true
+}
+
+// Reports every task and step that changed state since the last deploy status
+func (e *deployEvents) deployStatus(deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if e == nil || deployStatus == nil {
+		return
+	}
+	for _, taskStatus := range deployStatus.DeployTaskStatus {
+		taskState := ""
+		if taskStatus.CompletionTime != nil {
+			taskState = "completed"
+		} else if taskStatus.StartTime != nil {
+			taskState = "started"
+		}
+		if taskState != "" && e.setState(taskStatus.Name, taskState) {
+			e.emit(&deployEvent{Event: taskEvent, Task: taskStatus.Name, State: taskState})
+		}
+
+		for _, step := range taskStatus.Steps {
+			event := getStepEvent(step)
+			if event == nil || !e.setState(taskStatus.Name+"/"+step.Name, event.State) {
+				continue
+			}
+			event.Task = taskStatus.Name
+			e.emit(event)
+		}
+	}
+}
+
+func getStepEvent(step *svcmgmtv1alpha1.StepState) *deployEvent {
+	event := &deployEvent{Event: stepEvent, Step: step.Name}
+	if waiting := step.GetWaiting(); waiting != nil {
+		event.State = "waiting"
+		event.Reason = waiting.Reason
+		event.Message = waiting.Message
+	} else if running := step.GetRunning(); running != nil {
+		event.State = "running"
+	} else if terminated := step.GetTerminated(); terminated != nil {
+		event.State = "terminated"
+		event.Reason = terminated.Reason
+		event.Message = terminated.Message
+		exitCode := terminated.ExitCode
+		event.ExitCode = &exitCode
+	} else {
+		return nil
+	}
+	return event
+}
+
+// Records the state and reports whether it changed
+func (e *deployEvents) setState(key string, state string) bool {
+	if e.states[key] == state {
+		return false
+	}
+	e.states[key] = state
+	return true
+}
+
+// Reports a failed or cancelled pipeline, along with the task and step that failed if they are known
+func (e *deployEvents) pipelineFailed(deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if e == nil {
+		return
+	}
+	e.deployStatus(deployStatus)
+	event := &deployEvent{Event: deployFailedEvent}
+	if didPipelineGetCancelled(deployStatus) {
+		event.Event = deployCancelledEvent
+	}
+	if deployStatus.Succeeded != nil {
+		event.Reason = deployStatus.Succeeded.Reason
+		event.Message = deployStatus.Succeeded.Message
+	}
+	// every failed step has already been reported by a step event, the first one is enough here
+	if logRequests := getFailedStepLogRequests(deployStatus); len(logRequests) > 0 {
+		event.Task = logRequests[0].TaskName
+		event.Step = logRequests[0].TaskStep
+	}
+	e.emit(event)
+	e.finished = true
+}
+
+// Reports that the CLI stopped watching a deploy that is still running remotely.
+// The pipeline run is empty if nucleus hadn't reported it yet.
+func (e *deployEvents) detached(pipelineRun string) {
+	if e == nil {
+		return
+	}
+	e.emit(&deployEvent{Event: deployDetachedEvent, PipelineRun: pipelineRun})
+	e.finished = true
+}
+
+// Reports that the authz policy of the service couldn't be set.
+// The policy is set after the deploy has been reported, so this is emitted even once the deploy has finished.
+func (e *deployEvents) policyFailed(err error) {
+	if e == nil {
+		return
+	}
+	e.emit(&deployEvent{Event: policyFailedEvent, Error: err.Error()})
+	e.finished = true
+}
+
+// Reports the error the deploy ended with, unless its outcome has already been reported
+func (e *deployEvents) finish(err error) {
+	if e == nil || e.finished || err == nil {
+		return
+	}
+	e.emit(&deployEvent{Event: deployFailedEvent, Error: err.Error()})
+	e.finished = true
+}
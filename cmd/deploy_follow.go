@@ -0,0 +1,290 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/nucleuscloud/cli/internal/background"
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+const (
+	// how often the log of a deploy is checked for new records once everything written so far has been read
+	deployLogPollInterval = 500 * time.Millisecond
+)
+
+var (
+	errDeployFollowerStopped = errors.New("the background process following the deploy stopped before the deploy finished")
+)
+
+// A single line of the log of a deploy that is followed in the background.
+// Every response of the deploy stream is recorded, followed by the error the stream ended with, if any.
+type followRecord struct {
+	Time string `json:"time"`
+	// the DeployServiceResponse, as protojson
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// the deploy stream ended without a service url or a failed pipeline
+	Ended bool `json:"ended,omitempty"`
+}
+
+// Started by deploy --detach to keep the deploy stream open once the CLI has returned.
+// Closing the stream could end the deploy along with it, so it is only closed once the deploy has finished.
+var deployFollowCmd = &cobra.Command{
+	Use:    "follow <id>",
+	Short:  "Starts a recorded deploy and writes its progress to the deploy's log.",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		apiEnv, err := cmd.Flags().GetString("api-env")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		entry, err := config.GetDeploy(apiEnv, args[0])
+		if err != nil {
+			return err
+		}
+		logPath, err := config.GetDeployLogPath(entry.Id)
+		if err != nil {
+			return err
+		}
+		logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		return followDeploy(ctx, entry, json.NewEncoder(logFile))
+	},
+}
+
+func init() {
+	deployCmd.AddCommand(deployFollowCmd)
+
+	deployFollowCmd.Flags().String("api-env", string(clienv.ProdEnv), "nucleus api environment the deploy was recorded in")
+}
+
+// Starts the deploy recorded in the history entry and records its responses until it has finished
+func followDeploy(
+	ctx context.Context,
+	entry *config.DeployHistoryEntry,
+	log *json.Encoder,
+) error {
+	// every record is a single write, so readers never see part of a line unless it is still being written
+	writeRecord := func(record *followRecord) error {
+		record.Time = time.Now().UTC().Format(time.RFC3339Nano)
+		return log.Encode(record)
+	}
+	fail := func(err error) error {
+		_ = writeRecord(&followRecord{Error: err.Error()})
+		return err
+	}
+
+	conn, err := utils.NewApiConnectionByEnv(ctx, clienv.NucleusEnv(entry.ApiEnv))
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	svcClient := svcmgmtv1alpha1.NewServiceMgmtServiceClient(conn)
+
+	deployRequest, err := getDeployServiceRequest(*getRollbackRequest(entry))
+	if err != nil {
+		return fail(err)
+	}
+	deployRequest.UploadedCodeUri = entry.UploadKey
+	stream, err := startDeploy(ctx, svcClient, deployRequest, nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return writeRecord(&followRecord{Ended: true})
+		}
+		if err != nil {
+			return fail(err)
+		}
+		data, err := protojson.Marshal(response)
+		if err != nil {
+			return fail(err)
+		}
+		err = writeRecord(&followRecord{Response: data})
+		if err != nil {
+			return err
+		}
+		if response.GetServiceUrl() != "" || didPipelineFail(response.GetDeployStatus()) {
+			return nil
+		}
+	}
+}
+
+// Records the deploy of the uploaded code in the history and hands it to a background process that starts it
+// and keeps its stream open, then waits until nucleus has accepted the deploy.
+// The returned history entry holds the pipeline run, or the service url if the deploy already finished.
+func followDeployInBackground(req *deployRequest, uploadKey string) (*config.DeployHistoryEntry, error) {
+	history := newDeployHistoryEntry(req, uploadKey)
+	history.Outcome = config.DeployOutcomeRunning
+	logPath, err := config.GetDeployLogPath(history.Id)
+	if err != nil {
+		return nil, err
+	}
+	// created up front, so that it can be read before the background process has written to it
+	err = os.WriteFile(logPath, []byte{}, 0600)
+	if err != nil {
+		return nil, err
+	}
+	// the background process reads what to deploy from the history
+	err = config.RecordDeploy(history)
+	if err != nil {
+		return nil, fmt.Errorf("unable to record the deploy in the deploy history: %w", err)
+	}
+
+	pid, err := background.StartSelf("deploy", "follow", "--api-env", history.ApiEnv, history.Id)
+	if err != nil {
+		history.Outcome = ""
+		recordDeploy(history, err)
+		return nil, fmt.Errorf("unable to start the deploy in the background: %w", err)
+	}
+	history.FollowerPid = pid
+	recordDeploy(history, nil)
+
+	done := make(chan struct{})
+	defer close(done)
+	pipelineRun, serviceUrl, err := waitForDeployAccepted(tailDeployLog(history, done))
+	history.PipelineRun = pipelineRun
+	history.Url = serviceUrl
+	if err != nil || serviceUrl != "" {
+		// the outcome is known already, otherwise the deploy stays running until deploy status or watch settle it
+		history.Outcome = ""
+	}
+	recordDeploy(history, err)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Reads the log of a deploy that is followed in the background as the responses of its deploy stream,
+// waiting for more to be written for as long as the background process is running.
+// The channel is closed after the first error, which includes io.EOF. Closing done stops the reader.
+func tailDeployLog(entry *config.DeployHistoryEntry, done <-chan struct{}) <-chan *deployResponse {
+	responses := make(chan *deployResponse)
+	go func() {
+		defer close(responses)
+		send := func(response *deployResponse) bool {
+			select {
+			case responses <- response:
+				return response.err == nil
+			case <-done:
+				return false
+			}
+		}
+
+		logPath, err := config.GetDeployLogPath(entry.Id)
+		if err != nil {
+			send(&deployResponse{err: err})
+			return
+		}
+		logFile, err := os.Open(logPath)
+		if err != nil {
+			send(&deployResponse{err: fmt.Errorf("unable to read the log of deploy %s: %w", entry.Id, err)})
+			return
+		}
+		defer logFile.Close()
+
+		reader := bufio.NewReader(logFile)
+		line := []byte{}
+		stopped := false
+		for {
+			chunk, err := reader.ReadBytes('\n')
+			line = append(line, chunk...)
+			if err == io.EOF {
+				if stopped {
+					send(&deployResponse{err: errDeployFollowerStopped})
+					return
+				}
+				// whatever was written before the background process exited is read once more before giving up on it
+				stopped = !background.IsRunning(entry.FollowerPid)
+				if !stopped {
+					select {
+					case <-time.After(deployLogPollInterval):
+					case <-done:
+						return
+					}
+				}
+				continue
+			}
+			if err != nil {
+				send(&deployResponse{err: fmt.Errorf("unable to read the log of deploy %s: %w", entry.Id, err)})
+				return
+			}
+			record, err := parseFollowRecord(line)
+			line = line[:0]
+			if err != nil {
+				send(&deployResponse{err: err})
+				return
+			}
+			if !send(record.getResponse()) {
+				return
+			}
+		}
+	}()
+	return responses
+}
+
+func parseFollowRecord(line []byte) (*followRecord, error) {
+	record := &followRecord{}
+	err := json.Unmarshal(line, record)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the deploy log: %w", err)
+	}
+	return record, nil
+}
+
+// Returns the deploy stream response that the record stands for
+func (r *followRecord) getResponse() *deployResponse {
+	if r.Error != "" {
+		return &deployResponse{err: errors.New(r.Error)}
+	}
+	if r.Ended {
+		return &deployResponse{err: io.EOF}
+	}
+	response := &svcmgmtv1alpha1.DeployServiceResponse{}
+	err := protojson.Unmarshal(r.Response, response)
+	if err != nil {
+		return &deployResponse{err: fmt.Errorf("unable to read the deploy log: %w", err)}
+	}
+	return &deployResponse{response: response}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+var deployHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Lists the previous deploys of a service.",
+	Long: `Lists the previous deploys of a service to an environment, newest first.
+
+The history only holds deploys started from this machine, nucleus doesn't keep a history of deploys yet.
+Use the id of a deploy with rollback to deploy its artifact again, or with deploy status to see how it went.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environmentName, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+		if environmentName == "" {
+			return fmt.Errorf("must provide environment name")
+		}
+
+		serviceName, err := cmd.Flags().GetString("service")
+		if err != nil {
+			return err
+		}
+		serviceName = strings.TrimSpace(serviceName)
+		if serviceName == "" {
+			serviceName, err = getManifestServiceName()
+			if err != nil {
+				return err
+			}
+		}
+		if !utils.IsValidName(serviceName) {
+			return utils.ErrInvalidServiceName
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		deploys, err := config.GetDeployHistory(string(clienv.GetEnv()), environmentName, serviceName)
+		if err != nil {
+			return err
+		}
+		if len(deploys) == 0 {
+			fmt.Printf("No deploys of %s to %s were found\n", serviceName, environmentName)
+			return nil
+		}
+		for _, entry := range deploys {
+			// detached deploys are settled from the log of the background process following them
+			if entry.Outcome != config.DeployOutcomeRunning || entry.FollowerPid == 0 {
+				continue
+			}
+			_, err = settleDeploy(entry)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: unable to check on deploy %s: %s\n", entry.Id, err.Error())
+			}
+		}
+		printDeployHistory(deploys)
+		return nil
+	},
+}
+
+func init() {
+	deployCmd.AddCommand(deployHistoryCmd)
+
+	deployHistoryCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
+	deployHistoryCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if it defines a single service)")
+}
+
+// Returns the spec of the service selected with --service, so it can be changed and written back with the manifest
+func getManifestServiceSpec(cmd *cobra.Command, nucleusConfig *config.NucleusConfig) (*config.SpecStruct, error) {
+	serviceName, err := cmd.Flags().GetString("service")
+	if err != nil {
+		return nil, err
+	}
+	return config.GetServiceSpec(nucleusConfig, strings.TrimSpace(serviceName))
+}
+
+// Returns the name of the only service in the manifest, manifests with several services must select one with --service
+func getManifestServiceName() (string, error) {
+	if !config.DoesNucleusConfigExist() {
+		return "", fmt.Errorf("must provide a service name, no nucleus manifest was found")
+	}
+	deployConfig, err := config.GetNucleusConfig()
+	if err != nil {
+		return "", err
+	}
+	serviceConfigs, err := config.GetServiceConfigs(deployConfig)
+	if err != nil {
+		return "", err
+	}
+	if len(serviceConfigs) != 1 {
+		return "", fmt.Errorf("nucleus config defines %d services, must select one with --service", len(serviceConfigs))
+	}
+	return serviceConfigs[0].Spec.ServiceName, nil
+}
+
+func printDeployHistory(deploys []*config.DeployHistoryEntry) {
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	tbl := table.New("Id", "Time", "Pipeline Run", "Git Sha", "Artifact", "Outcome")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, entry := range deploys {
+		tbl.AddRow(
+			entry.Id,
+			entry.DeployedAt.Local().Format(time.RFC3339),
+			getEmptyLabel(entry.PipelineRun),
+			getEmptyLabel(getShortGitSha(entry.GitSha)),
+			getEmptyLabel(getDeployArtifact(entry)),
+			entry.Outcome,
+		)
+	}
+	tbl.Print()
+}
+
+// Returns the image or uploaded code that was deployed
+func getDeployArtifact(entry *config.DeployHistoryEntry) string {
+	if entry.Image != "" {
+		return entry.Image
+	}
+	return entry.UploadKey
+}
+
+func getShortGitSha(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+func getEmptyLabel(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// Starts the history entry of a deploy of the uploaded code, or of the image for docker services
+func newDeployHistoryEntry(req *deployRequest, uploadKey string) *config.DeployHistoryEntry {
+	id, err := config.NewDeployId()
+	if err != nil {
+		// recording the history must never fail a deploy
+		id = fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	entry := &config.DeployHistoryEntry{
+		Id:                 id,
+		ApiEnv:             string(clienv.GetEnv()),
+		EnvironmentName:    req.environmentName,
+		ServiceName:        req.serviceName,
+		DeployedAt:         time.Now().UTC(),
+		GitSha:             req.gitSha,
+		ServiceType:        req.serviceType,
+		UploadKey:          uploadKey,
+		Hash:               req.codeHash,
+		IsPrivate:          req.isPrivateService,
+		Vars:               req.envVars,
+		Secrets:            req.envSecrets,
+		BuildtimeVars:      req.buildTimeEnvVars,
+		Resources:          req.resources,
+		AllowedServices:    req.allowedServices,
+		DisallowedServices: req.disallowedServices,
+	}
+	if req.serviceType == "docker" {
+		entry.Image = req.image
+	}
+	return entry
+}
+
+// Records the deploy in the history with the outcome it ended with, unless an outcome was already set
+func recordDeploy(entry *config.DeployHistoryEntry, err error) {
+	if entry.Outcome == "" {
+		switch {
+		case entry.Url != "":
+			entry.Outcome = config.DeployOutcomeDeployed
+		case errors.Is(err, errDeployDetached):
+			entry.Outcome = config.DeployOutcomeRunning
+		case errors.Is(err, errDeployStreamEnded), errors.Is(err, errDeployFollowerStopped):
+			entry.Outcome = config.DeployOutcomeUnknown
+		case err != nil:
+			entry.Outcome = config.DeployOutcomeFailed
+		default:
+			entry.Outcome = config.DeployOutcomeUnknown
+		}
+	}
+	recordErr := config.RecordDeploy(entry)
+	if recordErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the deploy in the deploy history: %s\n", recordErr.Error())
+	}
+}
+
+// Returns the commit the service's directory is checked out at, or an empty string if it isn't in a git repository
+func getGitSha(dir string) string {
+	output, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,195 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/AlecAivazis/survey/v2"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"golang.org/x/term"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/progress"
+)
+
+const (
+	keepWatchingOption = "Keep watching the deploy"
+	detachOption       = "Stop watching and leave the deploy running"
+)
+
+var (
+	errDeployDetached = errors.New("stopped watching the deploy, it is still running remotely")
+)
+
+type deployResponse struct {
+	response *svcmgmtv1alpha1.DeployServiceResponse
+	err      error
+}
+
+// Receives from the deploy stream in the background so that the caller can wait on interrupts at the same time.
+// The channel is closed after the first error, which includes io.EOF. Closing done stops the receiver.
+func receiveDeployResponses(
+	stream svcmgmtv1alpha1.ServiceMgmtService_DeployServiceClient,
+	done <-chan struct{},
+) <-chan *deployResponse {
+	responses := make(chan *deployResponse)
+	go func() {
+		defer close(responses)
+		for {
+			response, err := stream.Recv()
+			select {
+			case responses <- &deployResponse{response: response, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return responses
+}
+
+// Returns a channel that receives Ctrl-C and SIGTERM, along with a func that restores the default handling
+func notifyInterrupts() (<-chan os.Signal, func()) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	return interrupts, func() { signal.Stop(interrupts) }
+}
+
+// Pauses the output on an interrupt and asks whether to keep watching the deploys.
+// Stopping closes the returned channel, which stops watching every deploy that is still running
+// and abandons the ones that haven't been started yet.
+// The returned func stops catching interrupts, it waits for a prompt that is still open to be answered.
+func watchInterrupts(output *servicesProgress, progressType progress.ProgressType) (<-chan struct{}, func()) {
+	detach := make(chan struct{})
+	allDone := make(chan struct{})
+	interruptsDone := make(chan struct{})
+	interrupts, stopInterrupts := notifyInterrupts()
+	go func() {
+		defer close(interruptsDone)
+		for {
+			select {
+			case <-interrupts:
+				output.pause()
+				if askToKeepWatching(progressType) {
+					output.resume()
+					continue
+				}
+				stopInterrupts()
+				output.stop()
+				close(detach)
+				return
+			case <-allDone:
+				return
+			}
+		}
+	}()
+	return detach, func() {
+		close(allDone)
+		<-interruptsDone
+		stopInterrupts()
+	}
+}
+
+// Asks whether to keep watching the deploy after an interrupt.
+// Nucleus has no way to cancel a running deploy, so the only other choice is to leave it running.
+// Cancelling belongs in these options once the service management api can cancel a deploy.
+// Without a terminal to ask on, or when interrupted again, the deploy is left running.
+func askToKeepWatching(progressType progress.ProgressType) bool {
+	if progressType == progress.JsonProgress || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Println()
+	fmt.Println("The deploy is still running remotely, stopping the CLI won't stop it. Nucleus can't cancel a running deploy yet.")
+	var answer string
+	err := survey.AskOne(&survey.Select{
+		Message: "What would you like to do?",
+		Options: []string{keepWatchingOption, detachOption},
+		Default: keepWatchingOption,
+	}, &answer, surveyIcons)
+	if err != nil {
+		return false
+	}
+	return answer == keepWatchingOption
+}
+
+// Tells the user how to check on deploys that were left running.
+// Deploys followed by a background process can be checked on by id, the others only through the services themselves.
+func printDetachedHelp(w io.Writer, deploys ...*config.DeployHistoryEntry) {
+	fmt.Fprintln(w)
+	for _, entry := range deploys {
+		fmt.Fprintf(w, "The deploy of %s to %s is still running remotely.\n", entry.ServiceName, entry.EnvironmentName)
+	}
+	fmt.Fprintln(w, "Check on it with:")
+	listedServices := false
+	for _, entry := range deploys {
+		if entry.FollowerPid != 0 {
+			fmt.Fprintf(w, "  nucleus deploy status %s\n", entry.Id)
+			fmt.Fprintf(w, "  nucleus deploy watch %s\n", entry.Id)
+			continue
+		}
+		if !listedServices {
+			// every service is deployed to the same environment
+			fmt.Fprintf(w, "  nucleus services list -e %s\n", entry.EnvironmentName)
+			listedServices = true
+		}
+		fmt.Fprintf(w, "  nucleus logs -e %s -s %s\n", entry.EnvironmentName, entry.ServiceName)
+	}
+}
+
+// Prints how a cancelled pipeline ended, the reason comes from the pipeline's status
+func printDeployCancelled(w io.Writer, deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if deployStatus.Succeeded != nil && deployStatus.Succeeded.Message != "" {
+		fmt.Fprintf(w, "\nDeploy was cancelled: %s\n", deployStatus.Succeeded.Message)
+		return
+	}
+	fmt.Fprintln(w, "\nDeploy was cancelled")
+}
+
+// Waits until nucleus has accepted the deploy and returns the pipeline run that is deploying it.
+// The service url is returned instead if the deploy finished before a pipeline run was reported.
+func waitForDeployAccepted(
+	responses <-chan *deployResponse,
+) (pipelineRun string, serviceUrl string, err error) {
+	for r := range responses {
+		response, err := r.response, r.err
+		if err != nil {
+			if err == io.EOF {
+				return "", "", fmt.Errorf("deploy ended before it was accepted")
+			}
+			return "", "", err
+		}
+		if response.GetServiceUrl() != "" {
+			return "", response.GetServiceUrl(), nil
+		}
+		deployStatus := response.GetDeployStatus()
+		if deployStatus == nil || deployStatus.PipelineRun == "" {
+			continue
+		}
+		if didPipelineFail(deployStatus) && !didPipelineGetCancelled(deployStatus) {
+			return "", "", fmt.Errorf("pipeline %s failed with error", deployStatus.PipelineRun)
+		}
+		return deployStatus.PipelineRun, "", nil
+	}
+	return "", "", fmt.Errorf("deploy ended before it was accepted")
+}
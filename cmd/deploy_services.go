@@ -0,0 +1,439 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/rodaine/table"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/progress"
+)
+
+type serviceDeployResult struct {
+	serviceName  string
+	serviceUrl   string
+	deployStatus *svcmgmtv1alpha1.DeployStatus
+	// set when the logs of the failed step were already streamed with the build logs
+	failureLogsShown bool
+	// set when the deploy was detached or interrupted, and left running remotely
+	detached    bool
+	pipelineRun string
+	// the deploy as recorded in the history, nil if it was never started
+	history *config.DeployHistoryEntry
+	err     error
+}
+
+// Deploys multiple services at once, with at most concurrency deploys running at a time.
+// All of the services share one progress display and failure logs are printed once every deploy has finished.
+func deployServices(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	reqs []*deployRequest,
+	progressType progress.ProgressType,
+	concurrency int,
+) error {
+	// json progress keeps stdout for events, everything else is meant for people
+	isJson := progressType == progress.JsonProgress
+	green := progress.SProgressPrint(progressType, color.FgGreen)
+	if !isJson {
+		fmt.Printf("\nGetting deployment ready: \n%sEnvironment: %s \n", green("↪"), reqs[0].environmentName)
+	}
+	for _, req := range reqs {
+		if !isJson && req.folderPath == "" {
+			// redeploying an earlier artifact, there is no directory involved
+			fmt.Printf("%sService: %s \n", green("↪"), req.serviceName)
+		} else if !isJson {
+			fmt.Printf("%sService: %s (%s) \n", green("↪"), req.serviceName, req.folderPath)
+		}
+	}
+	if !isJson {
+		fmt.Println()
+	}
+
+	output := newServicesProgress(ctx, progressType, reqs)
+	detach, stopInterrupts := watchInterrupts(output, progressType)
+
+	results := make([]*serviceDeployResult, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for idx, req := range reqs {
+		wg.Add(1)
+		go func(idx int, req *deployRequest) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[idx] = deployService(ctx, svcClient, req, progressType, output, idx, detach)
+			printPlainOutcome(output, idx, results[idx])
+		}(idx, req)
+	}
+	wg.Wait()
+	stopInterrupts()
+	output.wait()
+
+	numFailed := 0
+	for _, result := range results {
+		if result.err != nil {
+			numFailed++
+		}
+	}
+	if isJson {
+		// every outcome has already been reported as an event, logs go to stderr so they don't break the stream
+		printServiceFailureLogs(ctx, svcClient, reqs, results, os.Stderr)
+		return getDeployServicesErr(numFailed, printDetachedServices(results, os.Stderr), len(reqs), reqs[0].detach)
+	}
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	tbl := table.New("Service", "Status", "Url")
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+	for _, result := range results {
+		status := "Deployed"
+		if result.err != nil {
+			status = fmt.Sprintf("Failed: %s", result.err.Error())
+		} else if result.detached {
+			status = "Still running"
+			if result.pipelineRun != "" {
+				status = fmt.Sprintf("Still running: %s", result.pipelineRun)
+			}
+		} else if result.deployStatus != nil {
+			status = "Cancelled"
+		}
+		tbl.AddRow(result.serviceName, status, result.serviceUrl)
+	}
+	fmt.Println()
+	tbl.Print()
+
+	printServiceFailureLogs(ctx, svcClient, reqs, results, os.Stdout)
+	return getDeployServicesErr(numFailed, printDetachedServices(results, os.Stdout), len(reqs), reqs[0].detach)
+}
+
+// Prints the logs of every service whose pipeline failed
+func printServiceFailureLogs(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	reqs []*deployRequest,
+	results []*serviceDeployResult,
+	w io.Writer,
+) {
+	for idx, result := range results {
+		if result.err == nil || result.deployStatus == nil {
+			continue
+		}
+		req := reqs[idx]
+		output := w
+		if result.failureLogsShown {
+			// only the files are left to write
+			output = nil
+		} else {
+			fmt.Fprintf(w, "\nLogs for service '%s':\n", req.serviceName)
+		}
+		err := streamPodErrorLogs(ctx, svcClient, req.environmentName, req.serviceName, result.deployStatus, output, req.failureLogsDir)
+		if err != nil {
+			fmt.Fprintln(w, err)
+		}
+	}
+}
+
+// Prints how to check on every deploy that was left running, returns how many there were
+func printDetachedServices(results []*serviceDeployResult, w io.Writer) int {
+	detached := []*config.DeployHistoryEntry{}
+	for _, result := range results {
+		if result.detached {
+			detached = append(detached, result.history)
+		}
+	}
+	if len(detached) > 0 {
+		printDetachedHelp(w, detached...)
+	}
+	return len(detached)
+}
+
+func getDeployServicesErr(numFailed int, numDetached int, numServices int, detachRequested bool) error {
+	if numFailed > 0 {
+		return fmt.Errorf("%d of %d services failed to deploy", numFailed, numServices)
+	}
+	if numDetached > 0 && !detachRequested {
+		return fmt.Errorf("%d of %d services are still deploying remotely", numDetached, numServices)
+	}
+	return nil
+}
+
+// Prints how the deploy of the service ended in plain mode, before the summary of every service is printed
+func printPlainOutcome(output *servicesProgress, idx int, result *serviceDeployResult) {
+	switch {
+	case result.deployStatus != nil && result.err == nil:
+		output.printPlain(idx, "Deploy was cancelled")
+	case result.deployStatus != nil:
+		output.printPlain(idx, "Deploy failed")
+	case result.detached && result.history != nil && result.pipelineRun != "":
+		output.printPlain(idx, "Deploy %s started with pipeline run: %s", result.history.Id, result.pipelineRun)
+	case result.detached:
+		output.printPlain(idx, "Stopped watching the deploy, it is still running remotely")
+	case result.serviceUrl != "":
+		output.printPlain(idx, "Service is deployed at: %s", result.serviceUrl)
+	}
+}
+
+// Deploys a single service, on its own or as part of a multi service deploy, and shows its progress on the output.
+// The deploy status is set on the result if the pipeline failed or was cancelled.
+// Closing detach stops watching the deploy and leaves it running remotely.
+func deployService(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	progressType progress.ProgressType,
+	output *servicesProgress,
+	idx int,
+	detach <-chan struct{},
+) *serviceDeployResult {
+	result := &serviceDeployResult{serviceName: req.serviceName}
+	events := newDeployEvents(progressType, req)
+	events.started()
+	fail := func(err error) *serviceDeployResult {
+		output.abort(idx)
+		events.finish(err)
+		result.err = err
+		return result
+	}
+	// nothing has been started remotely until the deploy request is sent, so detaching before that leaves nothing behind
+	isDetached := func() bool {
+		select {
+		case <-detach:
+			return true
+		default:
+			return false
+		}
+	}
+	errInterrupted := fmt.Errorf("deploy was interrupted before it started")
+
+	deployRequest, err := getDeployServiceRequest(*req)
+	if err != nil {
+		return fail(err)
+	}
+
+	var reupload func() (string, error)
+	if req.uploadKey != "" {
+		deployRequest.UploadedCodeUri = req.uploadKey
+		reupload = getArtifactReupload(ctx, svcClient, req)
+	} else if req.serviceType != "docker" {
+		if isDetached() {
+			return fail(errInterrupted)
+		}
+		output.setStage(idx, "Bundling code...")
+		fd, summary, err := getCodeBundle(req)
+		if err != nil {
+			return fail(err)
+		}
+		defer removeBundle(fd)
+		req.codeHash = summary.Hash
+
+		err = checkCodeBundleSecrets(req, fd)
+		if err != nil {
+			return fail(err)
+		}
+		if isDetached() {
+			return fail(errInterrupted)
+		}
+
+		events.uploadStarted(summary.CompressedSize)
+		output.setStage(idx, "Uploading code...")
+		// detaching aborts the upload, there is no deploy to leave running yet
+		uploadCtx, cancelUpload := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-detach:
+				cancelUpload()
+			case <-uploadCtx.Done():
+			}
+		}()
+		// a detached deploy is started by a background process that has no bundle to upload again, so the key must be fresh
+		uploadKey, isCached, err := uploadCodeIfChanged(uploadCtx, svcClient, req, fd, summary.Hash, req.forceUpload || req.detach, output.uploadProgress(idx))
+		cancelUpload()
+		output.finishUpload(idx)
+		if isDetached() {
+			return fail(errInterrupted)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		events.uploadFinished(summary.CompressedSize, isCached)
+		if isCached {
+			output.println(idx, "Code is unchanged, reusing the previous upload")
+			reupload = func() (string, error) {
+				uploadKey, _, err := uploadCodeIfChanged(ctx, svcClient, req, fd, summary.Hash, true, nil)
+				return uploadKey, err
+			}
+		}
+		deployRequest.UploadedCodeUri = uploadKey
+	}
+
+	if isDetached() {
+		return fail(errInterrupted)
+	}
+
+	// the policy is set once the deploy is done, or as soon as it has been accepted when detaching
+	setPolicy := func() *serviceDeployResult {
+		err := setAuthzPolicy(
+			ctx,
+			svcClient,
+			req.environmentName,
+			req.serviceName,
+			req.allowedServices,
+			req.disallowedServices,
+		)
+		if err != nil {
+			events.policyFailed(err)
+			result.err = err
+		}
+		return result
+	}
+
+	output.setStage(idx, "Initiating deployment request")
+	if req.detach {
+		history, err := followDeployInBackground(req, deployRequest.UploadedCodeUri)
+		if err != nil {
+			return fail(err)
+		}
+		result.history = history
+		output.complete(idx)
+		if history.Url != "" {
+			result.serviceUrl = history.Url
+			events.deployed(history.Url)
+			return setPolicy()
+		}
+		events.detached(history.PipelineRun)
+		result.detached = true
+		result.pipelineRun = history.PipelineRun
+		return setPolicy()
+	}
+	stream, err := startDeploy(ctx, svcClient, deployRequest, reupload)
+	if err != nil {
+		return fail(err)
+	}
+	history := newDeployHistoryEntry(req, deployRequest.UploadedCodeUri)
+	result.history = history
+	defer func() {
+		history.Url = result.serviceUrl
+		history.PipelineRun = result.pipelineRun
+		if result.detached {
+			history.Outcome = config.DeployOutcomeRunning
+		} else if result.err == nil && result.deployStatus != nil {
+			history.Outcome = config.DeployOutcomeCancelled
+		}
+		recordDeploy(history, result.err)
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	watchServiceDeploy(ctx, svcClient, req, progressType, events, output, idx, result, receiveDeployResponses(stream, done), detach)
+	if result.serviceUrl == "" || result.err != nil {
+		return result
+	}
+	return setPolicy()
+}
+
+// Shows the progress of a deploy that has started until it is deployed, fails or is cancelled, and records how it ended on the result.
+// Closing detach stops watching the deploy and leaves it running remotely, without it the deploy is watched until it ends.
+func watchServiceDeploy(
+	ctx context.Context,
+	svcClient svcmgmtv1alpha1.ServiceMgmtServiceClient,
+	req *deployRequest,
+	progressType progress.ProgressType,
+	events *deployEvents,
+	output *servicesProgress,
+	idx int,
+	result *serviceDeployResult,
+	responses <-chan *deployResponse,
+	detach <-chan struct{},
+) {
+	fail := func(err error) {
+		output.abort(idx)
+		events.finish(err)
+		result.err = err
+	}
+	var buildLogs *buildLogTailer
+	if req.showBuildLogs {
+		prefix := ""
+		if output.isShared() {
+			prefix = req.serviceName + " "
+		}
+		buildLogs = newBuildLogTailer(ctx, svcClient, req, getBuildLogSink(progressType, output, events, prefix))
+	}
+	defer buildLogs.stop()
+
+	for {
+		var response *svcmgmtv1alpha1.DeployServiceResponse
+		var err error
+		select {
+		case <-detach:
+			output.abort(idx)
+			events.detached(result.pipelineRun)
+			result.detached = true
+			return
+		case r := <-responses:
+			response, err = r.response, r.err
+		}
+		if err != nil {
+			if err == io.EOF {
+				buildLogs.wait()
+				fail(errDeployStreamEnded)
+				return
+			}
+			fail(err)
+			return
+		}
+
+		if response.GetServiceUrl() != "" {
+			buildLogs.wait()
+			output.complete(idx)
+			result.serviceUrl = response.GetServiceUrl()
+			events.deployed(result.serviceUrl)
+			return
+		}
+
+		deployStatus := response.GetDeployStatus()
+		if deployStatus == nil {
+			continue
+		}
+		result.pipelineRun = deployStatus.PipelineRun
+
+		buildLogs.update(deployStatus)
+
+		if didPipelineFail(deployStatus) {
+			buildLogs.wait()
+			result.deployStatus = deployStatus
+			result.failureLogsShown = buildLogs.hasTailedFailedSteps(deployStatus)
+			output.fail(idx, deployStatus)
+			events.pipelineFailed(deployStatus)
+			if !didPipelineGetCancelled(deployStatus) {
+				fail(fmt.Errorf("pipeline failed with error"))
+			}
+			return
+		}
+
+		events.deployStatus(deployStatus)
+		output.update(idx, deployStatus)
+	}
+}
@@ -0,0 +1,386 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+
+	"github.com/nucleuscloud/cli/internal/progress"
+	"github.com/nucleuscloud/cli/internal/upload"
+)
+
+const (
+	// bars of a service are drawn together, every service gets this many priorities for its bars
+	serviceBarPriorities = 1000
+)
+
+// Output of a deploy, shared by every service that is deployed at once.
+// TTY progress shows what each service is doing until its pipeline starts, followed by one bar per pipeline task.
+// Build logs and progress lines are printed above the bars, and are prefixed with the service when several are deployed.
+// While the deploy is paused to ask the user something, nothing is drawn and every line is held back until it resumes.
+type servicesProgress struct {
+	ctx          context.Context
+	progressType progress.ProgressType
+	services     []*serviceProgress
+
+	mu        sync.Mutex
+	container *mpb.Progress
+	paused    bool
+	pending   bytes.Buffer
+}
+
+// Everything shown for a single service, so that its bars can be drawn again after a pause
+type serviceProgress struct {
+	name   string
+	prefix string
+	// what the service is doing until its pipeline reports a status
+	stage    string
+	stageBar *mpb.Bar
+	// bytes of the code bundle uploaded so far, the upload bar is only shown while uploading
+	uploadSent  int64
+	uploadTotal int64
+	uploadBar   *mpb.Bar
+	// last status of the pipeline, and its tasks once it has one
+	deployStatus *svcmgmtv1alpha1.DeployStatus
+	tasks        *taskProgress
+	// tasks that have been printed in plain mode
+	printedTasks map[string]int
+	done         bool
+}
+
+func newServicesProgress(ctx context.Context, progressType progress.ProgressType, reqs []*deployRequest) *servicesProgress {
+	p := &servicesProgress{
+		ctx:          ctx,
+		progressType: progressType,
+	}
+	for _, req := range reqs {
+		svc := &serviceProgress{name: req.serviceName, stage: "Waiting to deploy", printedTasks: map[string]int{}}
+		if len(reqs) > 1 {
+			svc.prefix = fmt.Sprintf("[%s] ", req.serviceName)
+		}
+		p.services = append(p.services, svc)
+	}
+	p.draw()
+	return p
+}
+
+// Reports whether several services share the output
+func (p *servicesProgress) isShared() bool {
+	return len(p.services) > 1
+}
+
+// Creates the bars of every service that is still deploying. Must be called with mu held.
+func (p *servicesProgress) draw() {
+	if p.progressType != progress.TtyProgress {
+		return
+	}
+	p.container = mpb.NewWithContext(p.ctx, mpb.WithWidth(progress.GetProgressBarWidth(30)))
+	for idx, svc := range p.services {
+		if svc.done {
+			continue
+		}
+		priority := idx * serviceBarPriorities
+		if svc.deployStatus != nil {
+			svc.tasks = newTaskProgress(p.container, svc.prefix, priority)
+			svc.tasks.update(svc.deployStatus)
+			continue
+		}
+		svc.stageBar = newStageBar(p.container, svc.prefix+svc.stage, priority)
+		if svc.uploadTotal > 0 {
+			svc.uploadBar = newUploadBar(p.container, svc.prefix+"Uploading code...", svc.uploadTotal, priority+1)
+			svc.uploadBar.SetCurrent(svc.uploadSent)
+		}
+	}
+}
+
+// Writes lines above the bars. Lines must be written whole.
+func (p *servicesProgress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return p.pending.Write(b)
+	}
+	if p.container == nil {
+		return os.Stdout.Write(b)
+	}
+	n, err := p.container.Write(b)
+	if errors.Is(err, mpb.DoneError) {
+		return os.Stdout.Write(b)
+	}
+	return n, err
+}
+
+// Prints a line about the service, unless progress is json
+func (p *servicesProgress) println(idx int, format string, a ...interface{}) {
+	if p.progressType == progress.JsonProgress {
+		return
+	}
+	fmt.Fprintf(p, "%s%s\n", p.services[idx].prefix, fmt.Sprintf(format, a...))
+}
+
+// Prints a plain progress line for the service, tty progress shows the same on its bars
+func (p *servicesProgress) printPlain(idx int, format string, a ...interface{}) {
+	if p.progressType != progress.PlainProgress {
+		return
+	}
+	p.println(idx, format, a...)
+}
+
+// Shows what the service is doing until its pipeline reports a status
+func (p *servicesProgress) setStage(idx int, stage string) {
+	p.printPlain(idx, "%s", stage)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	svc := p.services[idx]
+	svc.stage = stage
+	if p.paused || p.container == nil || svc.done {
+		return
+	}
+	if svc.stageBar != nil {
+		svc.stageBar.Abort(true)
+	}
+	svc.stageBar = newStageBar(p.container, svc.prefix+stage, idx*serviceBarPriorities)
+}
+
+// Returns the callback that reports the upload of the service's code bundle
+func (p *servicesProgress) uploadProgress(idx int) upload.ProgressFunc {
+	switch p.progressType {
+	case progress.JsonProgress:
+		// only the start and the end of the upload are reported, as events
+		return nil
+	case progress.PlainProgress:
+		return getPlainUploadProgress(func(percent int64) {
+			p.printPlain(idx, "Uploaded %d%%", percent)
+		})
+	}
+	return func(sent int64, total int64) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		svc := p.services[idx]
+		svc.uploadSent, svc.uploadTotal = sent, total
+		if p.paused || p.container == nil || svc.done {
+			return
+		}
+		if sent == 0 {
+			// the upload started over, the bar of the failed attempt is replaced once bytes are sent again
+			if svc.uploadBar != nil {
+				svc.uploadBar.Abort(true)
+				svc.uploadBar = nil
+			}
+			return
+		}
+		// the bar is created on the first update so it doesn't show up until bytes are being sent
+		if svc.uploadBar == nil {
+			svc.uploadBar = newUploadBar(p.container, svc.prefix+"Uploading code...", total, idx*serviceBarPriorities+1)
+		}
+		svc.uploadBar.SetCurrent(sent)
+	}
+}
+
+// Removes the upload bar of the service once its upload has finished
+func (p *servicesProgress) finishUpload(idx int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	svc := p.services[idx]
+	svc.uploadSent, svc.uploadTotal = 0, 0
+	if svc.uploadBar != nil {
+		svc.uploadBar.Abort(true)
+		svc.uploadBar = nil
+	}
+}
+
+// Shows the latest status of the service's pipeline
+func (p *servicesProgress) update(idx int, deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	p.printStatus(idx, deployStatus)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	svc := p.services[idx]
+	svc.deployStatus = deployStatus
+	if p.paused || p.container == nil || svc.done {
+		return
+	}
+	p.startTasks(idx)
+	svc.tasks.update(deployStatus)
+}
+
+// Replaces what the service was doing with the bars of its tasks. Must be called with mu held.
+func (p *servicesProgress) startTasks(idx int) {
+	svc := p.services[idx]
+	if svc.tasks != nil {
+		return
+	}
+	if svc.stageBar != nil {
+		svc.stageBar.Abort(true)
+		svc.stageBar = nil
+	}
+	svc.tasks = newTaskProgress(p.container, svc.prefix, idx*serviceBarPriorities)
+}
+
+// Prints the tasks that changed since the last status in plain mode.
+// A single service prints every task in full, several services only print which tasks completed.
+func (p *servicesProgress) printStatus(idx int, deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if p.progressType != progress.PlainProgress {
+		return
+	}
+	svc := p.services[idx]
+	if !p.isShared() {
+		buf := &bytes.Buffer{}
+		plainOutput(buf, deployStatus, svc.printedTasks)
+		_, _ = buf.WriteTo(p)
+		return
+	}
+	for _, taskStatus := range deployStatus.DeployTaskStatus {
+		if taskStatus.GetCompletionTime() == "" {
+			continue
+		}
+		if _, ok := svc.printedTasks[taskStatus.Name]; ok {
+			continue
+		}
+		svc.printedTasks[taskStatus.Name] = 1
+		p.printPlain(idx, "Task '%s' completed", taskStatus.Name)
+	}
+}
+
+// Completes the bars of the service once it is deployed
+func (p *servicesProgress) complete(idx int) {
+	p.finish(idx, func(svc *serviceProgress) {
+		svc.tasks.complete()
+	})
+}
+
+// Marks the task that failed the service's pipeline, and stops its other bars
+func (p *servicesProgress) fail(idx int, deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	p.printStatus(idx, deployStatus)
+	p.finish(idx, func(svc *serviceProgress) {
+		if svc.tasks == nil {
+			svc.deployStatus = deployStatus
+			p.startTasks(idx)
+		}
+		svc.tasks.fail(deployStatus)
+	})
+}
+
+// Stops the bars of the service when its deploy ends without an outcome
+func (p *servicesProgress) abort(idx int) {
+	p.finish(idx, func(svc *serviceProgress) {
+		svc.tasks.abort()
+	})
+}
+
+// Removes the bars of the service that show how its deploy is being started, and lets finishTasks end its task bars
+func (p *servicesProgress) finish(idx int, finishTasks func(svc *serviceProgress)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	svc := p.services[idx]
+	if svc.done {
+		return
+	}
+	svc.done = true
+	if p.paused || p.container == nil {
+		return
+	}
+	for _, bar := range []*mpb.Bar{svc.stageBar, svc.uploadBar} {
+		if bar != nil {
+			bar.Abort(true)
+		}
+	}
+	svc.stageBar, svc.uploadBar = nil, nil
+	finishTasks(svc)
+}
+
+// Stops drawing the bars, and holds back every line until resume or stop is called
+func (p *servicesProgress) pause() {
+	p.mu.Lock()
+	p.paused = true
+	container := p.container
+	for _, svc := range p.services {
+		for _, bar := range []*mpb.Bar{svc.stageBar, svc.uploadBar} {
+			if bar != nil {
+				bar.Abort(true)
+			}
+		}
+		svc.stageBar, svc.uploadBar = nil, nil
+		svc.tasks.abort()
+		svc.tasks = nil
+	}
+	p.mu.Unlock()
+	if container != nil {
+		container.Wait()
+	}
+}
+
+// Draws the bars of every service that is still deploying again, and prints the lines that were held back
+func (p *servicesProgress) resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draw()
+	p.unpause()
+}
+
+// Prints the lines that were held back, without drawing the bars again
+func (p *servicesProgress) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.container = nil
+	p.unpause()
+}
+
+// Must be called with mu held
+func (p *servicesProgress) unpause() {
+	p.paused = false
+	output := io.Writer(os.Stdout)
+	if p.container != nil {
+		output = p.container
+	}
+	_, _ = p.pending.WriteTo(output)
+}
+
+// Waits until every bar has finished drawing
+func (p *servicesProgress) wait() {
+	p.mu.Lock()
+	container := p.container
+	p.mu.Unlock()
+	if container != nil {
+		container.Wait()
+	}
+}
+
+// Returns a spinner followed by what a service is doing
+func newStageBar(container *mpb.Progress, stage string, priority int) *mpb.Bar {
+	green := color.New(color.FgGreen).SprintFunc()
+	frames := []string{}
+	for _, frame := range []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"} {
+		frames = append(frames, green(frame))
+	}
+	// a bar without a total only ends when it is aborted
+	return container.New(0,
+		mpb.SpinnerStyle(frames...).PositionLeft(),
+		mpb.BarWidth(1),
+		mpb.BarPriority(priority),
+		mpb.AppendDecorators(decor.Name(stage, decor.WC{C: decor.DextraSpace})),
+	)
+}
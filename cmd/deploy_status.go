@@ -0,0 +1,225 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/background"
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/term"
+)
+
+var deployStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Shows the status of a deploy.",
+	Long: `Shows the status of a deploy from the deploy history, found by its id or by its pipeline run.
+
+Deploys started with --detach are followed by a background process until they finish, so their status is up to date
+with nucleus. The deploy history and what the background process recorded are local, so only deploys started on this
+machine are found. The exit code is 1 if the deploy failed or its outcome isn't known, and 0 if it was deployed, was cancelled
+or is still running. Use deploy watch to follow the deploy until it finishes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := strings.TrimSpace(args[0])
+		if id == "" {
+			return fmt.Errorf("must provide the id or pipeline run of the deploy")
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		entry, err := config.GetDeploy(string(clienv.GetEnv()), id)
+		if err != nil {
+			return err
+		}
+		var followed *followedDeploy
+		if entry.FollowerPid != 0 {
+			followed, err = settleDeploy(entry)
+			if err != nil {
+				return err
+			}
+		}
+		printDeployStatus(entry, followed)
+		return getDeployOutcomeErr(entry, followed)
+	},
+}
+
+func init() {
+	deployCmd.AddCommand(deployStatusCmd)
+}
+
+// The progress of a deploy that is followed in the background, as recorded in its log
+type followedDeploy struct {
+	deployStatus *svcmgmtv1alpha1.DeployStatus
+	serviceUrl   string
+	// the error the deploy ended with, if it didn't end with a service url or a failed pipeline
+	err       error
+	updatedAt string
+}
+
+// Reads everything the background process following the deploy has recorded so far
+func readDeployLog(entry *config.DeployHistoryEntry) (*followedDeploy, error) {
+	// checked first, so that anything written before the process exited is read
+	isFollowed := background.IsRunning(entry.FollowerPid)
+
+	logPath, err := config.GetDeployLogPath(entry.Id)
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the log of deploy %s: %w", entry.Id, err)
+	}
+	defer logFile.Close()
+
+	followed := &followedDeploy{}
+	reader := bufio.NewReader(logFile)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a line without a newline is still being written
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the log of deploy %s: %w", entry.Id, err)
+		}
+		record, err := parseFollowRecord(line)
+		if err != nil {
+			return nil, err
+		}
+		followed.updatedAt = record.Time
+		response := record.getResponse()
+		switch {
+		case response.err == io.EOF:
+			followed.err = errDeployStreamEnded
+		case response.err != nil:
+			followed.err = response.err
+		case response.response.GetServiceUrl() != "":
+			followed.serviceUrl = response.response.GetServiceUrl()
+		case response.response.GetDeployStatus() != nil:
+			followed.deployStatus = response.response.GetDeployStatus()
+		}
+	}
+	if !isFollowed && followed.getOutcome() == config.DeployOutcomeRunning {
+		followed.err = errDeployFollowerStopped
+	}
+	return followed, nil
+}
+
+// Returns the outcome of the deploy as far as its log tells, the same way recordDeploy does for deploys that were watched
+func (d *followedDeploy) getOutcome() string {
+	switch {
+	case d.serviceUrl != "":
+		return config.DeployOutcomeDeployed
+	case didPipelineFail(d.deployStatus):
+		if didPipelineGetCancelled(d.deployStatus) {
+			return config.DeployOutcomeCancelled
+		}
+		return config.DeployOutcomeFailed
+	case errors.Is(d.err, errDeployStreamEnded), errors.Is(d.err, errDeployFollowerStopped):
+		return config.DeployOutcomeUnknown
+	case d.err != nil:
+		return config.DeployOutcomeFailed
+	}
+	return config.DeployOutcomeRunning
+}
+
+// Reads the progress of a deploy that is followed in the background,
+// and records its pipeline run, url and outcome in the history once it has finished
+func settleDeploy(entry *config.DeployHistoryEntry) (*followedDeploy, error) {
+	followed, err := readDeployLog(entry)
+	if err != nil {
+		return nil, err
+	}
+	outcome := followed.getOutcome()
+	if outcome == config.DeployOutcomeRunning || outcome == entry.Outcome {
+		return followed, nil
+	}
+	entry.Outcome = outcome
+	entry.Url = followed.serviceUrl
+	if followed.deployStatus != nil && followed.deployStatus.PipelineRun != "" {
+		entry.PipelineRun = followed.deployStatus.PipelineRun
+	}
+	err = config.RecordDeploy(entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to record the deploy in the deploy history: %w", err)
+	}
+	return followed, nil
+}
+
+// Prints the deploy from the history, along with the last status of its pipeline if it was followed in the background
+func printDeployStatus(entry *config.DeployHistoryEntry, followed *followedDeploy) {
+	green := term.GetColoredSprintFunc(color.FgGreen)
+	fmt.Printf("Deploy %s:\n", entry.Id)
+	fmt.Printf("%sService: %s\n", green("↪"), entry.ServiceName)
+	fmt.Printf("%sEnvironment: %s\n", green("↪"), entry.EnvironmentName)
+	fmt.Printf("%sStarted: %s\n", green("↪"), entry.DeployedAt.Local().Format(time.RFC3339))
+	fmt.Printf("%sPipeline Run: %s\n", green("↪"), getEmptyLabel(entry.PipelineRun))
+	if followed != nil && followed.updatedAt != "" {
+		if updatedAt, err := time.Parse(time.RFC3339Nano, followed.updatedAt); err == nil {
+			fmt.Printf("%sLast Update: %s\n", green("↪"), updatedAt.Local().Format(time.RFC3339))
+		}
+	}
+	fmt.Printf("%sOutcome: %s\n", green("↪"), entry.Outcome)
+
+	if followed != nil && followed.deployStatus != nil {
+		fmt.Println()
+		plainOutput(os.Stdout, followed.deployStatus, map[string]int{})
+	}
+	if entry.Url != "" {
+		fmt.Printf("\nService is deployed at: %s\n", green(entry.Url))
+	}
+}
+
+// Returns the error to exit with for the outcome of the deploy, or nil if it was deployed, was cancelled or is still running
+func getDeployOutcomeErr(entry *config.DeployHistoryEntry, followed *followedDeploy) error {
+	switch entry.Outcome {
+	case config.DeployOutcomeFailed:
+		if followed == nil {
+			return fmt.Errorf("deploy %s failed", entry.Id)
+		}
+		if followed.err != nil {
+			return fmt.Errorf("deploy %s failed: %w", entry.Id, followed.err)
+		}
+		return fmt.Errorf("pipeline of deploy %s failed, see the logs of the failed steps with: nucleus deploy watch %s", entry.Id, entry.Id)
+	case config.DeployOutcomeUnknown:
+		if followed != nil && followed.err != nil {
+			return fmt.Errorf("the outcome of deploy %s isn't known: %w", entry.Id, followed.err)
+		}
+		return fmt.Errorf("the outcome of deploy %s isn't known", entry.Id)
+	case config.DeployOutcomeRunning:
+		if entry.FollowerPid == 0 {
+			// nothing is following the deploy, so it will never be settled
+			return fmt.Errorf(
+				"the CLI stopped watching deploy %s before it finished, so its outcome isn't known, check on it with: nucleus services list -e %s",
+				entry.Id, entry.EnvironmentName,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+
+	nterm "github.com/nucleuscloud/cli/internal/term"
+)
+
+const (
+	taskPending   = "pending"
+	taskRunning   = "running"
+	taskCompleted = "completed"
+	taskFailed    = "failed"
+	taskCancelled = "cancelled"
+)
+
+// Renders one bar per pipeline task of a service in TTY mode, in a container that the caller waits on.
+// Each bar fills up as the task's steps terminate, and collapses into a single line once the task completes.
+// A nil *taskProgress is valid and renders nothing.
+type taskProgress struct {
+	container *mpb.Progress
+	// prepended to every task name, to tell services apart when several are deployed at once
+	prefix string
+	// bars are rendered in the order their tasks were first seen, starting at this priority
+	priority int
+	bars     map[string]*taskBar
+	order    []string
+}
+
+type taskBar struct {
+	bar  *mpb.Bar
+	name string
+
+	mu        sync.Mutex
+	state     string
+	step      string
+	stepState string
+	startTime time.Time
+	endTime   time.Time
+}
+
+func newTaskProgress(container *mpb.Progress, prefix string, priority int) *taskProgress {
+	return &taskProgress{
+		container: container,
+		prefix:    prefix,
+		priority:  priority,
+		bars:      map[string]*taskBar{},
+	}
+}
+
+// Updates every task bar from the latest deploy status
+func (p *taskProgress) update(deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if p == nil {
+		return
+	}
+	for _, taskStatus := range deployStatus.DeployTaskStatus {
+		tb := p.getTaskBar(taskStatus.Name)
+		tb.update(taskStatus)
+	}
+}
+
+// Marks the failed task red and stops every bar that is still running
+func (p *taskProgress) fail(deployStatus *svcmgmtv1alpha1.DeployStatus) {
+	if p == nil {
+		return
+	}
+	p.update(deployStatus)
+	state := taskFailed
+	if didPipelineGetCancelled(deployStatus) {
+		state = taskCancelled
+	}
+	// task name to the step that failed it, falling back to the last task when no step reported an error
+	failedTasks := map[string]string{}
+	for _, logRequest := range getFailedStepLogRequests(deployStatus) {
+		if _, ok := failedTasks[logRequest.TaskName]; !ok {
+			failedTasks[logRequest.TaskName] = logRequest.TaskStep
+		}
+	}
+	if len(failedTasks) == 0 {
+		if taskStatus := getLastTaskStatus(deployStatus.DeployTaskStatus); taskStatus != nil {
+			failedTasks[taskStatus.Name] = ""
+		}
+	}
+	for _, name := range p.order {
+		tb := p.bars[name]
+		if failedStep, ok := failedTasks[name]; ok {
+			tb.setState(state, failedStep)
+		}
+		tb.bar.Abort(false)
+	}
+}
+
+// Completes every bar once the service is deployed
+func (p *taskProgress) complete() {
+	if p == nil {
+		return
+	}
+	for _, name := range p.order {
+		tb := p.bars[name]
+		tb.setState(taskCompleted, "")
+		tb.bar.SetTotal(-1, true)
+	}
+}
+
+// Stops every bar when the deploy ends without an outcome
+func (p *taskProgress) abort() {
+	if p == nil {
+		return
+	}
+	for _, name := range p.order {
+		p.bars[name].bar.Abort(false)
+	}
+}
+
+func (p *taskProgress) getTaskBar(name string) *taskBar {
+	if tb, ok := p.bars[name]; ok {
+		return tb
+	}
+	tb := &taskBar{name: p.prefix + name, state: taskPending}
+	// the number of steps isn't known until the task starts, and a bar created without a total only completes when told to
+	tb.bar = p.container.New(0,
+		mpb.BarStyle().Lbound("╢").Filler("▌").Tip("▌").Padding("░").Rbound("╟"),
+		mpb.BarFillerClearOnComplete(),
+		mpb.BarFillerMiddleware(tb.colorFiller),
+		mpb.BarPriority(p.priority+len(p.order)),
+		mpb.PrependDecorators(
+			decor.Any(tb.decorName, decor.WC{C: decor.DSyncWidthR | decor.DextraSpace}),
+		),
+		mpb.AppendDecorators(
+			decor.Any(tb.decorElapsed, decor.WC{C: decor.DSyncWidthR | decor.DextraSpace}),
+			decor.Any(tb.decorStatus),
+		),
+	)
+	p.bars[name] = tb
+	p.order = append(p.order, name)
+	return tb
+}
+
+func (t *taskBar) update(taskStatus *svcmgmtv1alpha1.DeployTaskStatus) {
+	// decorators lock the task bar while the bar is rendering, so the bar can't be touched with the lock held
+	numTerminated, isCompleted := t.setStatus(taskStatus)
+	if isCompleted {
+		t.bar.SetTotal(-1, true)
+		return
+	}
+	if len(taskStatus.Steps) > 0 {
+		t.bar.SetTotal(int64(len(taskStatus.Steps)), false)
+		t.bar.SetCurrent(int64(numTerminated))
+	}
+}
+
+// Records the task's state and current step, and returns how many of its steps have terminated
+func (t *taskBar) setStatus(taskStatus *svcmgmtv1alpha1.DeployTaskStatus) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if taskStatus.StartTime != nil && t.startTime.IsZero() {
+		t.startTime = parseTaskTime(*taskStatus.StartTime)
+	}
+
+	// the current step is the first one that hasn't terminated yet, unless a step has failed
+	numTerminated := 0
+	failedStep := ""
+	t.step, t.stepState = "", ""
+	for _, step := range taskStatus.Steps {
+		if terminated := step.GetTerminated(); terminated != nil {
+			numTerminated++
+			if terminated.Reason == "Error" && failedStep == "" {
+				failedStep = step.Name
+			}
+			continue
+		}
+		if t.step == "" {
+			t.step = step.Name
+			t.stepState = getStepState(step)
+		}
+	}
+
+	switch {
+	case failedStep != "":
+		t.state = taskFailed
+		t.step = failedStep
+	case taskStatus.CompletionTime != nil:
+		t.state = taskCompleted
+	case taskStatus.StartTime != nil:
+		t.state = taskRunning
+	}
+	if taskStatus.CompletionTime != nil && t.endTime.IsZero() {
+		t.endTime = parseTaskTime(*taskStatus.CompletionTime)
+	}
+	return numTerminated, t.state == taskCompleted
+}
+
+// Returns the time nucleus reported for the task, or now if it can't be read
+func parseTaskTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Now()
+	}
+	return parsed
+}
+
+func (t *taskBar) setState(state string, step string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state = state
+	if step != "" {
+		t.step = step
+	}
+	if t.endTime.IsZero() && !t.startTime.IsZero() {
+		t.endTime = time.Now()
+	}
+}
+
+func getStepState(step *svcmgmtv1alpha1.StepState) string {
+	switch {
+	case step.GetWaiting() != nil:
+		return "waiting"
+	case step.GetRunning() != nil:
+		return "running"
+	case step.GetTerminated() != nil:
+		return "terminated"
+	}
+	return ""
+}
+
+func (t *taskBar) decorName(decor.Statistics) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.state {
+	case taskCompleted:
+		return nterm.GetColoredSprintFunc(color.FgGreen)("✓ ") + t.name
+	case taskFailed:
+		return nterm.GetColoredSprintFunc(color.FgRed)("✗ " + t.name)
+	case taskCancelled:
+		return nterm.GetColoredSprintFunc(color.FgYellow)("- ") + t.name
+	}
+	return "  " + t.name
+}
+
+func (t *taskBar) decorElapsed(decor.Statistics) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.startTime.IsZero() {
+		return ""
+	}
+	end := t.endTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(t.startTime).Round(time.Second).String()
+}
+
+func (t *taskBar) decorStatus(decor.Statistics) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.state {
+	case taskPending:
+		return taskPending
+	case taskCompleted:
+		return ""
+	case taskFailed, taskCancelled:
+		if t.step == "" {
+			return t.state
+		}
+		return fmt.Sprintf("%s at step %s", t.state, t.step)
+	}
+	if t.step == "" || t.stepState == "" {
+		return t.step
+	}
+	return fmt.Sprintf("%s (%s)", t.step, t.stepState)
+}
+
+// Turns the bar red once its task has failed
+func (t *taskBar) colorFiller(base mpb.BarFiller) mpb.BarFiller {
+	red := nterm.GetColoredSprintFunc(color.FgRed)
+	return mpb.BarFillerFunc(func(w io.Writer, stat decor.Statistics) error {
+		t.mu.Lock()
+		failed := t.state == taskFailed
+		t.mu.Unlock()
+		if !failed {
+			return base.Fill(w, stat)
+		}
+		buf := &bytes.Buffer{}
+		err := base.Fill(buf, stat)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, red(buf.String()))
+		return err
+	})
+}
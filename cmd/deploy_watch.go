@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/progress"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+var deployWatchCmd = &cobra.Command{
+	Use:   "watch <id>",
+	Short: "Shows the progress of a detached deploy until it finishes.",
+	Long: `Shows the progress of a deploy started with --detach until it is deployed, fails or is cancelled.
+The deploy is found by its id from deploy history, or by its pipeline run. Progress is read from the background process
+following the deploy on this machine, so only deploys started here with --detach can be watched.
+
+Progress is shown the same way deploy shows it, including the logs of failed steps and json events with --progress json.
+The exit code is 1 if the deploy failed or its outcome isn't known, and 0 otherwise. Interrupting watch (Ctrl-C) leaves
+the deploy running.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := cmd.Context()
+		id := strings.TrimSpace(args[0])
+		if id == "" {
+			return fmt.Errorf("must provide the id or pipeline run of the deploy")
+		}
+		failureLogsDir, err := cmd.Flags().GetString("failure-logs-dir")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		progressType, err := progress.ValidateAndRetrieveProgressFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		entry, err := config.GetDeploy(string(clienv.GetEnv()), id)
+		if err != nil {
+			return err
+		}
+		if entry.FollowerPid == 0 {
+			if entry.Outcome == config.DeployOutcomeRunning {
+				return getDeployOutcomeErr(entry, nil)
+			}
+			return fmt.Errorf("deploy %s wasn't started with --detach, see how it ended with: nucleus deploy status %s", entry.Id, entry.Id)
+		}
+
+		conn, err := utils.NewApiConnectionByEnv(ctx, clienv.GetEnv())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		svcClient := svcmgmtv1alpha1.NewServiceMgmtServiceClient(conn)
+
+		req := getRollbackRequest(entry)
+		req.failureLogsDir = failureLogsDir
+		events := newDeployEvents(progressType, req)
+
+		if progressType != progress.JsonProgress {
+			green := progress.SProgressPrint(progressType, color.FgGreen)
+			fmt.Printf("\nWatching deploy %s: \n%sService: %s \n%sEnvironment: %s \n\n",
+				entry.Id,
+				green("↪"), entry.ServiceName,
+				green("↪"), entry.EnvironmentName,
+			)
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		// the outcome is recorded from the log once the deploy has finished, not from what was shown
+		output := newServicesProgress(ctx, progressType, []*deployRequest{req})
+		result := &serviceDeployResult{serviceName: req.serviceName, pipelineRun: entry.PipelineRun}
+		watchServiceDeploy(ctx, svcClient, req, progressType, events, output, 0, result, tailDeployLog(entry, done), nil)
+		output.wait()
+		err = reportServiceDeploy(ctx, svcClient, req, progressType, result)
+		_, settleErr := settleDeploy(entry)
+		if settleErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to record the outcome of deploy %s: %s\n", entry.Id, settleErr.Error())
+		}
+		return err
+	},
+}
+
+func init() {
+	deployCmd.AddCommand(deployWatchCmd)
+
+	deployWatchCmd.Flags().String("failure-logs-dir", "", "write the logs of every failed pipeline step to its own file in this directory")
+	progress.AttachProgressFlag(deployWatchCmd)
+}
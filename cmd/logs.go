@@ -42,11 +42,10 @@ var logsCommand = &cobra.Command{
 		serviceName := strings.TrimSpace(sn)
 		if serviceName == "" {
 			if config.DoesNucleusConfigExist() {
-				cfg, err := config.GetNucleusConfig()
+				serviceName, err = getManifestServiceName()
 				if err != nil {
 					return err
 				}
-				serviceName = cfg.Spec.ServiceName
 			}
 		}
 
@@ -143,7 +142,7 @@ func init() {
 	logsCommand.Flags().StringP("env", "e", "", "set the nucleus environment")
 	logsCommand.Flags().BoolP("tail", "t", false, "live log tail")
 	logsCommand.Flags().BoolP("follow", "f", false, "live log tail")
-	logsCommand.Flags().StringP("service", "s", "", "service name")
+	logsCommand.Flags().StringP("service", "s", "", "service name, if not provided will pull from nucleus.yaml (if it defines a single service)")
 	logsCommand.Flags().StringP("window", "w", "", "logging window allowed values: [15min, 1h, 1d]")
 	logsCommand.Flags().StringP("pod", "p", "", "specific pod to pull logs from")
 	logsCommand.Flags().Int64("max-lines", 0, "will return only the max number of lines. 0 means all")
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/term"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrades the nucleus manifest file to the latest schema version.",
+	Long:  "Upgrades the nucleus manifest file to the schema version used by this CLI. Comments and formatting of the manifest are kept where possible.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		manifestPath := config.GetNucleusConfigPath()
+		if dryRun {
+			data, err := os.ReadFile(manifestPath)
+			if err != nil {
+				return err
+			}
+			output, _, err := config.MigrateNucleusConfig(data)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(output))
+			return nil
+		}
+
+		fromVersion, err := config.MigrateNucleusConfigFile()
+		if err != nil {
+			return err
+		}
+		green := term.GetColoredSprintFunc(color.FgGreen)
+		if fromVersion == config.CurrentCliVersion {
+			fmt.Printf("%s is already at version %s\n", manifestPath, green(config.CurrentCliVersion))
+			return nil
+		}
+		if fromVersion == "" {
+			fromVersion = "unversioned"
+		}
+		fmt.Printf("Migrated %s from %s to %s\n", manifestPath, fromVersion, green(config.CurrentCliVersion))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().Bool("dry-run", false, "print the migrated manifest instead of writing it")
+}
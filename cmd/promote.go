@@ -0,0 +1,280 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/progress"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Deploys the artifact that was last deployed to one environment to another.",
+	Long: `Deploys the code or image that was last deployed successfully to the --from environment to the --to environment,
+without bundling the service directory again. The vars, secrets and resources of the --to environment are taken from the manifest.
+
+The artifact is found in the deploy history of this machine, see 'nucleus deploy history'.
+If nucleus doesn't accept the uploaded code in the --to environment, the service directory is bundled again and uploaded to it,
+as long as it still holds exactly the code that was deployed. Otherwise the service has to be deployed to --to instead.
+The config changes since the last deploy to the --to environment are printed before deploying.
+Promoting to an environment marked as protected in the manifest must be confirmed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		deployConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+
+		fromEnvironmentName, err := cmd.Flags().GetString("from")
+		if err != nil {
+			return err
+		}
+		toEnvironmentName, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+		if fromEnvironmentName == "" || toEnvironmentName == "" {
+			return fmt.Errorf("must provide the environment to promote from and to")
+		}
+		if strings.EqualFold(fromEnvironmentName, toEnvironmentName) {
+			return fmt.Errorf("must promote to a different environment than %s", fromEnvironmentName)
+		}
+
+		deployAll, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		serviceNames, err := cmd.Flags().GetStringSlice("service")
+		if err != nil {
+			return err
+		}
+		if deployAll && len(serviceNames) > 0 {
+			return fmt.Errorf("must provide either --all or --service, not both")
+		}
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			return err
+		}
+		if concurrency < 1 {
+			return fmt.Errorf("concurrency must be greater than 0")
+		}
+
+		serviceConfigs, err := config.GetServiceConfigs(deployConfig)
+		if err != nil {
+			return err
+		}
+		if !deployAll {
+			serviceConfigs, err = config.SelectServiceConfigs(serviceConfigs, serviceNames)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		progressType, err := progress.ValidateAndRetrieveProgressFlag(cmd)
+		if err != nil {
+			return err
+		}
+		useLocalEnv, err := cmd.Flags().GetBool("local-env")
+		if err != nil {
+			return err
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return err
+		}
+
+		apiEnv := string(clienv.GetEnv())
+		reqs := []*deployRequest{}
+		isProtected := false
+		for _, svc := range serviceConfigs {
+			req, err := getPromoteRequest(apiEnv, deployConfig.CliVersion, fromEnvironmentName, toEnvironmentName, svc, useLocalEnv)
+			if err != nil {
+				return err
+			}
+			reqs = append(reqs, req)
+			if config.IsProtectedEnv(&svc.Spec, toEnvironmentName) {
+				isProtected = true
+			}
+		}
+
+		// json progress keeps stdout for events
+		output := io.Writer(os.Stdout)
+		if progressType == progress.JsonProgress {
+			output = os.Stderr
+		}
+		for _, req := range reqs {
+			err = printPromoteChanges(output, progressType, apiEnv, req)
+			if err != nil {
+				return err
+			}
+		}
+
+		if isProtected {
+			if progressType == progress.JsonProgress && !yes {
+				return fmt.Errorf("%s is a protected environment, must provide --yes to promote to it with json progress", toEnvironmentName)
+			}
+			err = utils.PromptToProceed(cmd, toEnvironmentName, "yes")
+			if err != nil {
+				return err
+			}
+		}
+
+		conn, err := utils.NewApiConnectionByEnv(ctx, clienv.GetEnv())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		svcClient := svcmgmtv1alpha1.NewServiceMgmtServiceClient(conn)
+
+		if len(reqs) > 1 {
+			return deployServices(ctx, svcClient, reqs, progressType, concurrency)
+		}
+
+		req := reqs[0]
+		return deploy(ctx, svcClient, *req, progressType)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+
+	promoteCmd.Flags().String("from", "", "environment whose last deploy is promoted")
+	promoteCmd.Flags().String("to", "", "environment to deploy to")
+	promoteCmd.Flags().Bool("all", false, "promote every service defined in the nucleus manifest")
+	promoteCmd.Flags().StringSliceP("service", "s", []string{}, "comma separated list of services from the nucleus manifest to promote")
+	promoteCmd.Flags().Int("concurrency", 3, "max number of services to deploy at once")
+	promoteCmd.Flags().Bool("local-env", false, "allow vars to reference variables from the local environment")
+	promoteCmd.Flags().BoolP("yes", "y", false, "automatically proceed when promoting to a protected environment")
+	progress.AttachProgressFlag(promoteCmd)
+}
+
+// Returns a request that deploys the artifact last deployed to the from environment, with the config of the to environment
+func getPromoteRequest(
+	apiEnv string,
+	cliVersion string,
+	fromEnvironmentName string,
+	toEnvironmentName string,
+	svc config.ServiceConfig,
+	useLocalEnv bool,
+) (*deployRequest, error) {
+	err := validateServiceSpec(&svc.Spec)
+	if err != nil {
+		return nil, err
+	}
+	source, err := getLastSuccessfulDeploy(apiEnv, fromEnvironmentName, svc.Spec.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("no successful deploy of %s to %s was found in the deploy history of this machine", svc.Spec.ServiceName, fromEnvironmentName)
+	}
+
+	req, err := getDeployRequest(cliVersion, toEnvironmentName, svc, useLocalEnv, false)
+	if err != nil {
+		return nil, err
+	}
+	if source.ServiceType != req.serviceType {
+		return nil, fmt.Errorf("%s was deployed to %s as a %s service, but is now a %s service", req.serviceName, fromEnvironmentName, source.ServiceType, req.serviceType)
+	}
+	// nothing is bundled, the artifact is deployed as is.
+	// Upload keys may be scoped to the environment they were uploaded to, in which case the code is uploaded to it again.
+	req.artifactSource = fmt.Sprintf("deploy %s to %s", source.Id, fromEnvironmentName)
+	req.artifactDir = req.folderPath
+	req.folderPath = ""
+	req.uploadKey = source.UploadKey
+	req.codeHash = source.Hash
+	req.image = source.Image
+	req.gitSha = source.GitSha
+	return req, nil
+}
+
+// Returns the newest deploy of the service to the environment that finished successfully, or nil if there is none
+func getLastSuccessfulDeploy(apiEnv string, environmentName string, serviceName string) (*config.DeployHistoryEntry, error) {
+	deploys, err := config.GetDeployHistory(apiEnv, environmentName, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range deploys {
+		if entry.Outcome == config.DeployOutcomeDeployed {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// Prints the config changes the promotion makes compared to the last deploy to the target environment
+func printPromoteChanges(w io.Writer, progressType progress.ProgressType, apiEnv string, req *deployRequest) error {
+	previous, err := getLastSuccessfulDeploy(apiEnv, req.environmentName, req.serviceName)
+	if err != nil {
+		return err
+	}
+	changes := config.DiffDeployConfig(previous, newDeployHistoryEntry(req, req.uploadKey))
+
+	if previous == nil {
+		fmt.Fprintf(w, "\nNo earlier deploy of %s to %s was found in the deploy history, all of its config is new:\n", req.serviceName, req.environmentName)
+	} else {
+		fmt.Fprintf(w, "\nChanges to %s in %s since deploy %s:\n", req.serviceName, req.environmentName, previous.Id)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "  no changes")
+		return nil
+	}
+
+	green := progress.SProgressPrint(progressType, color.FgGreen)
+	red := progress.SProgressPrint(progressType, color.FgRed)
+	yellow := progress.SProgressPrint(progressType, color.FgYellow)
+	for _, change := range changes {
+		symbol := getChangeSymbol(change, green, red, yellow)
+		switch {
+		case change.Sensitive:
+			// secret values are encrypted, only whether they changed is useful
+			fmt.Fprintf(w, "  %s %s (%s)\n", symbol, change.Field, change.Kind)
+		case change.Kind == config.ChangeAdded:
+			fmt.Fprintf(w, "  %s %s: %s\n", symbol, change.Field, formatSingleLine(change.New))
+		case change.Kind == config.ChangeRemoved:
+			fmt.Fprintf(w, "  %s %s: %s\n", symbol, change.Field, formatSingleLine(change.Old))
+		default:
+			fmt.Fprintf(w, "  %s %s: %s -> %s\n", symbol, change.Field, formatSingleLine(change.Old), formatSingleLine(change.New))
+		}
+	}
+	return nil
+}
+
+func getChangeSymbol(change *config.DeployConfigChange, green, red, yellow func(a ...interface{}) string) string {
+	switch change.Kind {
+	case config.ChangeAdded:
+		return green("+")
+	case config.ChangeRemoved:
+		return red("-")
+	}
+	return yellow("~")
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	svcmgmtv1alpha1 "github.com/nucleuscloud/mgmt-api/gen/proto/go/servicemgmt/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	clienv "github.com/nucleuscloud/cli/internal/env"
+	"github.com/nucleuscloud/cli/internal/progress"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Deploys the artifact of an earlier deploy again.",
+	Long: `Deploys the code or image of an earlier deploy again, with the vars, secrets and resources it was deployed with.
+
+Find the id of the deploy to roll back to with 'nucleus deploy history'. Only deploys that finished successfully can be rolled back to.
+Uploaded code is only kept by nucleus for a limited time, so rolling back to an old code deploy can fail.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		id, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return fmt.Errorf("must provide the id of the deploy to roll back to")
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		entry, err := config.GetDeploy(string(clienv.GetEnv()), id)
+		if err != nil {
+			return err
+		}
+		if entry.Outcome != config.DeployOutcomeDeployed {
+			return fmt.Errorf("deploy %s did not finish successfully (%s), only successful deploys can be rolled back to", entry.Id, entry.Outcome)
+		}
+		if entry.UploadKey == "" && entry.Image == "" {
+			return fmt.Errorf("deploy %s has no recorded artifact to deploy again", entry.Id)
+		}
+
+		progressType, err := progress.ValidateAndRetrieveProgressFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		// json progress keeps stdout for events
+		output := os.Stdout
+		if progressType == progress.JsonProgress {
+			output = os.Stderr
+		}
+		fmt.Fprintf(output, "Rolling back to deploy %s from %s:\n↪Environment: %s\n↪Service: %s\n↪Artifact: %s\n",
+			entry.Id, entry.DeployedAt.Local().Format("2006-01-02 15:04:05"), entry.EnvironmentName, entry.ServiceName, getDeployArtifact(entry))
+		if entry.GitSha != "" {
+			fmt.Fprintf(output, "↪Git Sha: %s\n", entry.GitSha)
+		}
+
+		err = utils.PromptToProceed(cmd, entry.EnvironmentName, "yes")
+		if err != nil {
+			return err
+		}
+
+		conn, err := utils.NewApiConnectionByEnv(ctx, clienv.GetEnv())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		svcClient := svcmgmtv1alpha1.NewServiceMgmtServiceClient(conn)
+
+		req := getRollbackRequest(entry)
+		return deploy(ctx, svcClient, *req, progressType)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().String("to", "", "id of the deploy to roll back to, from 'nucleus deploy history'")
+	rollbackCmd.Flags().BoolP("yes", "y", false, "automatically proceed with the rollback")
+	progress.AttachProgressFlag(rollbackCmd)
+}
+
+// Returns a deploy request that deploys the recorded artifact with the recorded config
+func getRollbackRequest(entry *config.DeployHistoryEntry) *deployRequest {
+	return &deployRequest{
+		cliVersion:         config.CurrentCliVersion,
+		environmentName:    entry.EnvironmentName,
+		serviceName:        entry.ServiceName,
+		serviceType:        entry.ServiceType,
+		image:              entry.Image,
+		isPrivateService:   entry.IsPrivate,
+		envVars:            entry.Vars,
+		envSecrets:         entry.Secrets,
+		resources:          entry.Resources,
+		buildTimeEnvVars:   entry.BuildtimeVars,
+		uploadKey:          entry.UploadKey,
+		codeHash:           entry.Hash,
+		gitSha:             entry.GitSha,
+		allowedServices:    entry.AllowedServices,
+		disallowedServices: entry.DisallowedServices,
+	}
+}
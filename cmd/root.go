@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/viper"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/nucleuscloud/cli/internal/config"
 	"github.com/nucleuscloud/cli/internal/utils"
 	"github.com/nucleuscloud/cli/internal/version"
 )
@@ -35,7 +36,10 @@ const (
 	cliSettingsFileExt       = "yaml"
 )
 
-var verbose bool
+var (
+	verbose      bool
+	manifestFile string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -45,6 +49,12 @@ var rootCmd = &cobra.Command{
 	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
 		cmd.SilenceErrors = true
 
+		manifestPath := manifestFile
+		if manifestPath == "" {
+			manifestPath = os.Getenv(config.NucleusManifestEnvKey)
+		}
+		config.SetNucleusConfigPath(manifestPath)
+
 		versionInfo := version.Get()
 		md := metadata.New(map[string]string{
 			"cliVersion":  versionInfo.GitVersion,
@@ -71,6 +81,7 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", fmt.Sprintf("config file (default is $HOME/%s.%s)", cliSettingsFileNameNoExt, cliSettingsFileExt))
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVarP(&manifestFile, "file", "m", "", fmt.Sprintf("path to the nucleus manifest (default is ./nucleus.yaml, can also be set with %s)", config.NucleusManifestEnvKey))
 
 	rootCmd.Version = version.Get().GitVersion
 	rootCmd.SetVersionTemplate(`{{printf "%s\n" .Version}}`)
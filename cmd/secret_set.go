@@ -49,7 +49,11 @@ var setCmd = &cobra.Command{
 			return err
 		}
 
-		if !utils.IsValidName(deployConfig.Spec.ServiceName) {
+		spec, err := getManifestServiceSpec(cmd, deployConfig)
+		if err != nil {
+			return err
+		}
+		if !utils.IsValidName(spec.ServiceName) {
 			return utils.ErrInvalidServiceName
 		}
 
@@ -84,7 +88,7 @@ var setCmd = &cobra.Command{
 
 		publicKeyReply, err := svcClient.GetPublicSecretKey(ctx, &svcmgmtv1alpha1.GetPublicSecretKeyRequest{
 			EnvironmentName: environmentName,
-			ServiceName:     deployConfig.Spec.ServiceName,
+			ServiceName:     spec.ServiceName,
 		})
 		if err != nil {
 			return err
@@ -98,7 +102,7 @@ var setCmd = &cobra.Command{
 		if verbose {
 			fmt.Println("Encrypting secret...")
 		}
-		err = secrets.StoreSecret(&deployConfig.Spec, publicKey, secretKey, secretResult.value, environmentName)
+		err = secrets.StoreSecret(spec, publicKey, secretKey, secretResult.value, environmentName)
 		if err != nil {
 			return err
 		}
@@ -166,4 +170,5 @@ func init() {
 	secretCmd.AddCommand(setCmd)
 
 	setCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
+	setCmd.Flags().StringP("service", "s", "", "service from the nucleus manifest, required if it defines more than one")
 }
@@ -47,6 +47,15 @@ var servicesDependenciesAllowCmd = &cobra.Command{
 			return fmt.Errorf("must provide environment name")
 		}
 
+		nucleusConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+		spec, err := getManifestServiceSpec(cmd, nucleusConfig)
+		if err != nil {
+			return err
+		}
+
 		// Set this after ensuring flags are correct
 		cmd.SilenceUsage = true
 
@@ -54,15 +63,11 @@ var servicesDependenciesAllowCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		nucleusConfig, err := config.GetNucleusConfig()
-		if err != nil {
-			return err
-		}
 
 		serviceExcludeSet := map[string]struct{}{
-			nucleusConfig.Spec.ServiceName: {},
+			spec.ServiceName: {},
 		}
-		for _, svc := range nucleusConfig.Spec.AllowedServices {
+		for _, svc := range spec.AllowedServices {
 			serviceExcludeSet[svc] = struct{}{}
 		}
 
@@ -82,7 +87,7 @@ var servicesDependenciesAllowCmd = &cobra.Command{
 			return err
 		}
 
-		return storeServiceDependency(depName)
+		return storeServiceDependency(nucleusConfig, spec, depName)
 	},
 }
 
@@ -90,6 +95,7 @@ func init() {
 	servicesDependenciesCmd.AddCommand(servicesDependenciesAllowCmd)
 
 	servicesDependenciesAllowCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
+	servicesDependenciesAllowCmd.Flags().StringP("service", "s", "", "service from the nucleus manifest, required if it defines more than one")
 }
 
 func getServiceNamesByEnvironment(ctx context.Context, environmentName string) ([]string, error) {
@@ -121,19 +127,15 @@ func getServiceNamesByEnvironment(ctx context.Context, environmentName string) (
 	return serviceNames, nil
 }
 
-func storeServiceDependency(val string) error {
-	nucleusConfig, err := config.GetNucleusConfig()
-	if err != nil {
-		return err
-	}
-
-	for _, allowedSvc := range nucleusConfig.Spec.AllowedServices {
+// Adds the dependency to the spec's allowed services and writes the manifest back
+func storeServiceDependency(nucleusConfig *config.NucleusConfig, spec *config.SpecStruct, val string) error {
+	for _, allowedSvc := range spec.AllowedServices {
 		if allowedSvc == val {
 			fmt.Println("This service is already in the allowed services list")
 			return nil
 		}
 	}
 
-	nucleusConfig.Spec.AllowedServices = append(nucleusConfig.Spec.AllowedServices, val)
+	spec.AllowedServices = append(spec.AllowedServices, val)
 	return config.SetNucleusConfig(nucleusConfig)
 }
@@ -51,12 +51,11 @@ var servicesRemoveCmd = &cobra.Command{
 		serviceName = strings.TrimSpace(serviceName)
 		if serviceName == "" {
 			if config.DoesNucleusConfigExist() {
-				cfg, err := config.GetNucleusConfig()
+				serviceName, err = getManifestServiceName()
 				if err != nil {
 					fmt.Fprintln(os.Stderr, fmt.Errorf("Did not provide service name and could not find nucleus config"))
 					return err
 				}
-				serviceName = cfg.Spec.ServiceName
 			}
 		}
 
@@ -82,7 +81,7 @@ func init() {
 	servicesCmd.AddCommand(servicesRemoveCmd)
 
 	servicesRemoveCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
-	servicesRemoveCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if there is one)")
+	servicesRemoveCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if it defines a single service)")
 	servicesRemoveCmd.Flags().BoolP("yes", "y", false, "automatically proceed with removal")
 }
 
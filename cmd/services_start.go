@@ -48,12 +48,11 @@ var servicesStartCmd = &cobra.Command{
 		serviceName = strings.TrimSpace(serviceName)
 		if serviceName == "" {
 			if config.DoesNucleusConfigExist() {
-				cfg, err := config.GetNucleusConfig()
+				serviceName, err = getManifestServiceName()
 				if err != nil {
 					fmt.Fprintln(os.Stderr, fmt.Errorf("Did not provide service name and could not find nucleus config"))
 					return err
 				}
-				serviceName = cfg.Spec.ServiceName
 			}
 		}
 
@@ -72,5 +71,5 @@ func init() {
 	servicesCmd.AddCommand(servicesStartCmd)
 
 	servicesStartCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
-	servicesStartCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if there is one)")
+	servicesStartCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if it defines a single service)")
 }
@@ -50,12 +50,11 @@ var servicesStopCmd = &cobra.Command{
 		serviceName = strings.TrimSpace(serviceName)
 		if serviceName == "" {
 			if config.DoesNucleusConfigExist() {
-				cfg, err := config.GetNucleusConfig()
+				serviceName, err = getManifestServiceName()
 				if err != nil {
 					fmt.Println("Did not provide service name and could not find nucleus config")
 					return err
 				}
-				serviceName = cfg.Spec.ServiceName
 			}
 		}
 
@@ -74,7 +73,7 @@ func init() {
 	servicesCmd.AddCommand(servicesStopCmd)
 
 	servicesStopCmd.Flags().StringP("env", "e", "", "set the nucleus environment")
-	servicesStopCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if there is one)")
+	servicesStopCmd.Flags().StringP("service", "s", "", "set the service name, if not provided will pull from nucleus.yaml (if it defines a single service)")
 }
 
 func setServicePause(ctx context.Context, environmentName string, serviceName string, isPaused bool) error {
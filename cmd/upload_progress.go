@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+
+	"github.com/nucleuscloud/cli/internal/upload"
+)
+
+const (
+	// how often, in percent, plain progress reports the upload
+	plainUploadProgressStep = 10
+)
+
+// Returns a byte level bar with the transfer rate for an upload of total bytes
+func newUploadBar(container *mpb.Progress, label string, total int64, priority int) *mpb.Bar {
+	return container.New(total,
+		mpb.BarStyle().Lbound("╢").Filler("▌").Tip("▌").Padding("░").Rbound("╟"),
+		mpb.BarPriority(priority),
+		mpb.PrependDecorators(
+			decor.Name(label, decor.WC{W: len(label) + 1, C: decor.DidentRight}),
+			decor.CountersKibiByte("% .1f / % .1f"),
+		),
+		mpb.AppendDecorators(
+			decor.AverageSpeed(decor.UnitKiB, "% .1f", decor.WCSyncSpace),
+		),
+	)
+}
+
+// Returns a callback that calls report every time the upload crosses another progress step, starting over when the upload does
+func getPlainUploadProgress(report func(percent int64)) upload.ProgressFunc {
+	nextPercent := int64(plainUploadProgressStep)
+	return func(sent int64, total int64) {
+		if sent == 0 {
+			// the upload started over, so every step is reported again
+			nextPercent = plainUploadProgressStep
+			return
+		}
+		if total <= 0 {
+			return
+		}
+		percent := sent * 100 / total
+		if percent < nextPercent {
+			return
+		}
+		report(percent - percent%plainUploadProgressStep)
+		nextPercent = percent - percent%plainUploadProgressStep + plainUploadProgressStep
+	}
+}
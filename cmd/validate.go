@@ -0,0 +1,100 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/term"
+	"github.com/nucleuscloud/cli/internal/validate"
+)
+
+type validateOutput struct {
+	File   string            `json:"file"`
+	Valid  bool              `json:"valid"`
+	Issues []*validate.Issue `json:"issues"`
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates the nucleus manifest file without deploying it.",
+	Long:  "Runs every check that can be done offline against the nucleus manifest file and reports all of the problems that were found, along with their line and column.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if output != "" && output != "json" {
+			return fmt.Errorf("must provide valid output")
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		manifestPath := config.GetNucleusConfigPath()
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return err
+		}
+
+		issues, err := validate.ValidateManifest(data)
+		if err != nil {
+			return fmt.Errorf("%s is not valid yaml: %w", manifestPath, err)
+		}
+
+		if output == "json" {
+			marshalled, err := json.MarshalIndent(&validateOutput{
+				File:   manifestPath,
+				Valid:  len(issues) == 0,
+				Issues: issues,
+			}, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(marshalled))
+		} else {
+			printIssues(manifestPath, issues)
+		}
+
+		if len(issues) > 0 {
+			return fmt.Errorf("found %d issue(s) in %s", len(issues), manifestPath)
+		}
+		return nil
+	},
+}
+
+func printIssues(manifestPath string, issues []*validate.Issue) {
+	if len(issues) == 0 {
+		green := term.GetColoredSprintFunc(color.FgGreen)
+		fmt.Println(green(fmt.Sprintf("%s is valid", manifestPath)))
+		return
+	}
+	for _, issue := range issues {
+		fmt.Printf("%s:%d:%d: %s\n", manifestPath, issue.Line, issue.Column, issue.String())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringP("output", "o", "", "output format, one of: json")
+}
@@ -31,4 +31,6 @@ var varCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(varCmd)
+
+	varCmd.PersistentFlags().StringP("service", "s", "", "service from the nucleus manifest, required if it defines more than one")
 }
@@ -0,0 +1,78 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/dotenv"
+	"github.com/spf13/cobra"
+)
+
+var varExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Prints the environment variables in your nucleus manifest.",
+	Long:  "Prints the environment variables in your nucleus manifest as a .env file, JSON, or shell export statements. For ex. nucleus var export -o shell > vars.sh",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		format, ok := dotenv.ParseFormat(output)
+		if !ok {
+			return fmt.Errorf("output must be one of: %s", strings.Join(dotenv.GetFormats(), ", "))
+		}
+		environmentName, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		vars, err := getManifestVars(cmd, environmentName)
+		if err != nil {
+			return err
+		}
+		return dotenv.Write(os.Stdout, vars, format)
+	},
+}
+
+// Returns the vars of the selected service in the manifest, including the overrides of the environment if one is provided
+func getManifestVars(cmd *cobra.Command, environmentName string) (map[string]string, error) {
+	nucleusConfig, err := config.GetNucleusConfig()
+	if err != nil {
+		return nil, err
+	}
+	spec, err := getManifestServiceSpec(cmd, nucleusConfig)
+	if err != nil {
+		return nil, err
+	}
+	if environmentName == "" {
+		return spec.Vars, nil
+	}
+	return config.GetSpecForEnv(spec, environmentName).Vars, nil
+}
+
+func init() {
+	varCmd.AddCommand(varExportCmd)
+
+	varExportCmd.Flags().StringP("output", "o", string(dotenv.DotenvFormat), fmt.Sprintf("output format (%s)", strings.Join(dotenv.GetFormats(), ", ")))
+	varExportCmd.Flags().StringP("env", "e", "", "include the var overrides of the nucleus environment")
+}
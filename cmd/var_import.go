@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/dotenv"
+	"github.com/nucleuscloud/cli/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var varImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Imports environment variables from a .env file into your nucleus manifest.",
+	Long:  "Imports environment variables from a .env file into your nucleus manifest. Existing vars with the same key are overwritten. Use - to read from stdin. For ex. nucleus var import .env",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		replace, err := cmd.Flags().GetBool("replace")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		vars, err := readDotenvFile(args[0])
+		if err != nil {
+			return err
+		}
+		for key := range vars {
+			err = utils.ValidateVarKey(key)
+			if err != nil {
+				return err
+			}
+		}
+
+		nucleusConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+		spec, err := getManifestServiceSpec(cmd, nucleusConfig)
+		if err != nil {
+			return err
+		}
+		if replace || spec.Vars == nil {
+			spec.Vars = make(map[string]string)
+		}
+		for key, value := range vars {
+			spec.Vars[key] = value
+		}
+
+		err = config.SetNucleusConfig(nucleusConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d var(s) from %s\n", len(vars), args[0])
+		return nil
+	},
+}
+
+func readDotenvFile(path string) (map[string]string, error) {
+	var reader io.Reader
+	if path == "-" {
+		reader = os.Stdin
+	} else {
+		fd, err := os.Open(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("file %s does not exist", path)
+			}
+			return nil, err
+		}
+		defer fd.Close()
+		reader = fd
+	}
+
+	vars, err := dotenv.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+func init() {
+	varCmd.AddCommand(varImportCmd)
+
+	varImportCmd.Flags().Bool("replace", false, "remove all existing vars before importing")
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var varListCmd = &cobra.Command{
+	Use: "list",
+	Aliases: []string{
+		"ls",
+	},
+	Short: "Lists the environment variables in your nucleus manifest.",
+	Long:  "Lists the environment variables in your nucleus manifest. Provide an environment to include its overrides.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environmentName, err := cmd.Flags().GetString("env")
+		if err != nil {
+			return err
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		vars, err := getManifestVars(cmd, environmentName)
+		if err != nil {
+			return err
+		}
+		if len(vars) == 0 {
+			fmt.Println("No vars have been set")
+			return nil
+		}
+
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+		columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+		tbl := table.New("Key", "Value")
+		tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+		for _, key := range keys {
+			value := vars[key]
+			if strings.ContainsAny(value, "\r\n") {
+				// keep multiline values on a single row
+				value = strconv.Quote(value)
+			}
+			tbl.AddRow(key, value)
+		}
+		tbl.Print()
+		return nil
+	},
+}
+
+func init() {
+	varCmd.AddCommand(varListCmd)
+
+	varListCmd.Flags().StringP("env", "e", "", "include the var overrides of the nucleus environment")
+}
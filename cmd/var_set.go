@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -34,7 +35,10 @@ var varSetCmd = &cobra.Command{
 			return errors.New("must provide at least one environment variable key-value pair")
 		}
 
-		err := storeVars(args)
+		// Set this after ensuring args are correct
+		cmd.SilenceUsage = true
+
+		err := storeVars(cmd, args)
 		if err != nil {
 			return err
 		}
@@ -43,31 +47,38 @@ var varSetCmd = &cobra.Command{
 	},
 }
 
-func storeVars(args []string) error {
+func storeVars(cmd *cobra.Command, args []string) error {
+	vars := map[string]string{}
+	for _, arg := range args {
+		// only split on the first = so values can contain it
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("var is not in KEY=VALUE format: %s", arg)
+		}
+		err := utils.ValidateVarKey(key)
+		if err != nil {
+			return err
+		}
+		vars[key] = value
+	}
+
 	nucleusConfig, err := config.GetNucleusConfig()
 	if err != nil {
 		return err
 	}
-
-	if nucleusConfig.Spec.Vars == nil {
-		nucleusConfig.Spec.Vars = make(map[string]string)
+	spec, err := getManifestServiceSpec(cmd, nucleusConfig)
+	if err != nil {
+		return err
 	}
 
-	for i := 0; i < len(args); i++ {
-		s := strings.Split(args[i], "=")
-		if len(s) == 2 {
-			nucleusConfig.Spec.Vars[s[0]] = s[1]
-		} else {
-			fmt.Printf("Skipping var because not in right format: %s\n", args[i])
-		}
+	if spec.Vars == nil {
+		spec.Vars = make(map[string]string)
 	}
-
-	err = config.SetNucleusConfig(nucleusConfig)
-
-	if err != nil {
-		return err
+	for key, value := range vars {
+		spec.Vars[key] = value
 	}
-	return nil
+
+	return config.SetNucleusConfig(nucleusConfig)
 }
 
 func init() {
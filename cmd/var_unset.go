@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var varUnsetCmd = &cobra.Command{
+	Use:   "unset KEY...",
+	Short: "Removes environment variables from your nucleus manifest.",
+	Long:  "Removes environment variables from your nucleus manifest. Remove multiple by separating them with a space. For ex. nucleus var unset KEY1 KEY2",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("must provide at least one environment variable key")
+		}
+
+		// Set this after ensuring flags are correct
+		cmd.SilenceUsage = true
+
+		nucleusConfig, err := config.GetNucleusConfig()
+		if err != nil {
+			return err
+		}
+		spec, err := getManifestServiceSpec(cmd, nucleusConfig)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range args {
+			if _, ok := spec.Vars[key]; !ok {
+				fmt.Printf("Skipping var because it is not set: %s\n", key)
+				continue
+			}
+			delete(spec.Vars, key)
+		}
+		if len(spec.Vars) == 0 {
+			spec.Vars = nil
+		}
+
+		return config.SetNucleusConfig(nucleusConfig)
+	},
+}
+
+func init() {
+	varCmd.AddCommand(varUnsetCmd)
+}
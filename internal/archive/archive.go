@@ -0,0 +1,311 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// Filter reports whether a path should be left out of the archive.
+// Paths are slash separated and relative to the archive root, and directories end with a slash.
+type Filter func(path string, isDir bool) bool
+
+// Leaves out every path that matches one of the gitignore style patterns
+func IgnorePatterns(patterns ...string) Filter {
+	ignorer := gitignore.CompileIgnoreLines(patterns...)
+	return func(path string, isDir bool) bool {
+		return ignorer.MatchesPath(path)
+	}
+}
+
+// Leaves out every file that matches none of the gitignore style patterns.
+// Directories are always walked so that included files in them are found.
+func IncludePatterns(patterns ...string) Filter {
+	includer := gitignore.CompileIgnoreLines(patterns...)
+	return func(path string, isDir bool) bool {
+		if isDir {
+			return false
+		}
+		return !includer.MatchesPath(path)
+	}
+}
+
+var (
+	// every entry gets the same timestamp so archives of the same files are byte for byte identical
+	fixedModTime = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+)
+
+// Summary describes an archive that was written
+type Summary struct {
+	// sha256 of the uncompressed tarball, which only changes when the archived files change
+	Hash string
+	// the archived files, in archive order
+	Files []*File
+	// total size of the archived files before compression
+	TotalSize int64
+	// size of the gzipped archive
+	CompressedSize int64
+	// symlinks that were left out because they wouldn't point to the same file once extracted
+	Warnings []*Warning
+}
+
+// Warning describes a symlink that was left out of the archive
+type Warning struct {
+	Name    string
+	Message string
+}
+
+func (w *Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Name, w.Message)
+}
+
+type File struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// Writes the contents of the source directory into w as a gzipped tarball.
+// Regular files, directories and symlinks are archived, and anything matched by one of the filters is left out.
+// Symlinks are kept as links, and one that is absolute or points outside of the source is left out with a warning.
+// The archive is deterministic: entries are sorted, and timestamps and owners are fixed.
+func GzipDirectory(source string, w io.Writer, filters ...Filter) (*Summary, error) {
+	counter := &countingWriter{writer: w}
+	gw := gzip.NewWriter(counter)
+	hash := sha256.New()
+	summary := &Summary{Files: []*File{}, Warnings: []*Warning{}}
+	err := tarDirectory(source, io.MultiWriter(gw, hash), filters, summary)
+	if err != nil {
+		gw.Close()
+		return nil, err
+	}
+	err = gw.Close()
+	if err != nil {
+		return nil, err
+	}
+	summary.Hash = formatHash(hash.Sum(nil))
+	summary.CompressedSize = counter.written
+	return summary, nil
+}
+
+// Summarizes an existing gzipped tarball.
+// The hash matches the one GzipDirectory returned if the archive was written by it.
+func ReadSummary(r io.Reader) (*Summary, error) {
+	counter := &countingReader{reader: r}
+	gr, err := gzip.NewReader(counter)
+	if err != nil {
+		return nil, fmt.Errorf("archive is not gzipped: %w", err)
+	}
+	defer gr.Close()
+
+	hash := sha256.New()
+	tarReader := io.TeeReader(gr, hash)
+	summary := &Summary{Files: []*File{}, Warnings: []*Warning{}}
+	tr := tar.NewReader(tarReader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		summary.Files = append(summary.Files, &File{Name: header.Name, Size: header.Size})
+		summary.TotalSize += header.Size
+	}
+	// the tar reader stops at the end of archive marker, the padding after it is part of the hash too
+	_, err = io.Copy(io.Discard, tarReader)
+	if err != nil {
+		return nil, err
+	}
+	summary.Hash = formatHash(hash.Sum(nil))
+	summary.CompressedSize = counter.read
+	return summary, nil
+}
+
+func formatHash(sum []byte) string {
+	return fmt.Sprintf("sha256:%x", sum)
+}
+
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+func tarDirectory(source string, w io.Writer, filters []Filter, summary *Summary) error {
+	tw := tar.NewWriter(w)
+	err := walkDirectory(source, filters, func(name string, file string, fi os.FileInfo) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    int64(fi.Mode().Perm()),
+			ModTime: fixedModTime,
+			Format:  tar.FormatPAX,
+		}
+		switch {
+		case fi.IsDir():
+			header.Typeflag = tar.TypeDir
+		case isSymlink(fi):
+			linkname, warning, err := getLinkname(name, file)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				summary.Warnings = append(summary.Warnings, &Warning{Name: name, Message: warning})
+				return nil
+			}
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = linkname
+		default:
+			header.Typeflag = tar.TypeReg
+			header.Size = fi.Size()
+		}
+		err := tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		summary.Files = append(summary.Files, &File{Name: name, Size: fi.Size()})
+		summary.TotalSize += fi.Size()
+
+		fd, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		_, err = io.Copy(tw, fd)
+		return err
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func isSymlink(fi os.FileInfo) bool {
+	return fi.Mode()&os.ModeSymlink != 0
+}
+
+// Returns the target of the symlink as it is stored in the archive.
+// Targets must be relative and stay inside the archive, or the link would point somewhere else once it is extracted,
+// otherwise the reason the symlink can't be archived is returned instead.
+func getLinkname(name string, file string) (string, string, error) {
+	target, err := os.Readlink(file)
+	if err != nil {
+		return "", "", err
+	}
+	linkname := filepath.ToSlash(target)
+	if filepath.IsAbs(target) || path.IsAbs(linkname) {
+		return "", fmt.Sprintf("points to an absolute path (%s), make it relative or ignore it", target), nil
+	}
+	resolved := path.Join(path.Dir(name), linkname)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", fmt.Sprintf("points outside of the directory (%s), ignore it or replace it with the file", target), nil
+	}
+	return linkname, "", nil
+}
+
+// Returns the paths in the source directory that the filters leave out of the archive.
+// Ignored directories are listed once, without their contents.
+func ListIgnored(source string, filters ...Filter) ([]string, error) {
+	ignored := []string{}
+	err := walkDirectory(source, filters, func(name string, file string, fi os.FileInfo) error {
+		return nil
+	}, func(name string) {
+		ignored = append(ignored, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ignored, nil
+}
+
+// Walks the regular files, directories and symlinks of the source in lexical order. Symlinks are never followed.
+// onFile is called with the archive name of everything that is kept, and onIgnored with the ones that are filtered.
+func walkDirectory(
+	source string,
+	filters []Filter,
+	onFile func(name string, file string, fi os.FileInfo) error,
+	onIgnored func(name string),
+) error {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(absSource)
+	if err != nil {
+		return fmt.Errorf("unable to archive directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("can only archive a directory")
+	}
+
+	return filepath.Walk(absSource, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() && !fi.IsDir() && !isSymlink(fi) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absSource, file)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		name := filepath.ToSlash(relPath)
+		if fi.IsDir() {
+			name += "/"
+		}
+		if isFiltered(filters, name, fi.IsDir()) {
+			if onIgnored != nil {
+				onIgnored(name)
+			}
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return onFile(name, file, fi)
+	})
+}
+
+func isFiltered(filters []Filter, path string, isDir bool) bool {
+	for _, filter := range filters {
+		if filter(path, isDir) {
+			return true
+		}
+	}
+	return false
+}
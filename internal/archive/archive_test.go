@@ -0,0 +1,190 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzipDirectory(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".git/HEAD":         "ref",
+		"main.go":           "package main",
+		"README.md":         "readme",
+		"src/app.go":        "package src",
+		"src/app_test.go":   "package src",
+		"docs/guide.md":     "guide",
+		"node_modules/a.js": "a",
+	})
+
+	names := getArchiveNames(t, dir)
+	assert.Equal(t, []string{".git/", ".git/HEAD", "README.md", "docs/", "docs/guide.md", "main.go", "node_modules/", "node_modules/a.js", "src/", "src/app.go", "src/app_test.go"}, names)
+
+	names = getArchiveNames(t, dir, IgnorePatterns("**/.git", "node_modules/", "*_test.go"))
+	assert.Equal(t, []string{"README.md", "docs/", "docs/guide.md", "main.go", "src/", "src/app.go"}, names)
+
+	names = getArchiveNames(t, dir, IgnorePatterns("**/.git"), IncludePatterns("src", "*.md"))
+	assert.Equal(t, []string{"README.md", "docs/", "docs/guide.md", "node_modules/", "src/", "src/app.go", "src/app_test.go"}, names)
+}
+
+func TestGzipDirectory_Summary(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go":    "package main",
+		"src/app.go": "package src // app",
+	})
+
+	buf := &bytes.Buffer{}
+	summary, err := GzipDirectory(dir, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []*File{{Name: "main.go", Size: 12}, {Name: "src/app.go", Size: 18}}, summary.Files)
+	assert.Equal(t, int64(30), summary.TotalSize)
+	assert.Equal(t, int64(buf.Len()), summary.CompressedSize)
+
+	read, err := ReadSummary(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, summary, read)
+
+	_, err = ReadSummary(bytes.NewReader([]byte("not gzip")))
+	assert.Error(t, err)
+}
+
+func TestGzipDirectory_Errors(t *testing.T) {
+	dir := writeTree(t, map[string]string{"file.txt": "text"})
+	_, err := GzipDirectory(filepath.Join(dir, "file.txt"), io.Discard)
+	assert.Error(t, err)
+	_, err = GzipDirectory(filepath.Join(dir, "idontexist"), io.Discard)
+	assert.Error(t, err)
+}
+
+func TestGzipDirectory_Deterministic(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go":    "package main",
+		"src/app.go": "package src",
+	})
+
+	first := &bytes.Buffer{}
+	summary, err := GzipDirectory(dir, first)
+	assert.Nil(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", summary.Hash)
+
+	// touching files must not change the archive
+	later := time.Now().Add(time.Hour)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "main.go"), later, later))
+	second := &bytes.Buffer{}
+	summary2, err := GzipDirectory(dir, second)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Hash, summary2.Hash)
+	assert.Equal(t, first.Bytes(), second.Bytes())
+
+	gr, err := gzip.NewReader(second)
+	assert.Nil(t, err)
+	header, err := tar.NewReader(gr).Next()
+	assert.Nil(t, err)
+	assert.True(t, fixedModTime.Equal(header.ModTime))
+	assert.Equal(t, 0, header.Uid)
+	assert.Equal(t, "", header.Uname)
+
+	read, err := ReadSummary(bytes.NewReader(first.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Hash, read.Hash)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // changed"), 0644))
+	summary3, err := GzipDirectory(dir, io.Discard)
+	assert.Nil(t, err)
+	assert.NotEqual(t, summary.Hash, summary3.Hash)
+}
+
+func TestGzipDirectory_Symlinks(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go":       "package main",
+		"config/app.go": "package config",
+	})
+	if err := os.Symlink("main.go", filepath.Join(dir, "link.go")); err != nil {
+		// creating symlinks on windows requires developer mode or admin rights
+		t.Skipf("unable to create symlinks: %s", err)
+	}
+	assert.Nil(t, os.Symlink("../main.go", filepath.Join(dir, "config", "main.go")))
+	assert.Nil(t, os.Symlink("config", filepath.Join(dir, "settings")))
+
+	buf := &bytes.Buffer{}
+	summary, err := GzipDirectory(dir, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []*File{{Name: "config/app.go", Size: 14}, {Name: "main.go", Size: 12}}, summary.Files, "links are not files")
+
+	gr, err := gzip.NewReader(buf)
+	assert.Nil(t, err)
+	tr := tar.NewReader(gr)
+	links := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if header.Typeflag == tar.TypeSymlink {
+			links[header.Name] = header.Linkname
+		}
+	}
+	assert.Equal(t, map[string]string{"config/main.go": "../main.go", "link.go": "main.go", "settings": "config"}, links)
+
+	assert.Empty(t, summary.Warnings)
+
+	outside := filepath.Join(dir, "outside")
+	assert.Nil(t, os.Symlink("../../etc/passwd", outside))
+	summary, err = GzipDirectory(dir, io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Warning{{Name: "outside", Message: "points outside of the directory (../../etc/passwd), ignore it or replace it with the file"}}, summary.Warnings)
+	assert.NotContains(t, getArchiveNames(t, dir), "outside")
+	summary, err = GzipDirectory(dir, io.Discard, IgnorePatterns("outside"))
+	assert.Nil(t, err)
+	assert.Empty(t, summary.Warnings, "ignored links should not be checked")
+	assert.Nil(t, os.Remove(outside))
+
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "main.go"), outside))
+	summary, err = GzipDirectory(dir, io.Discard)
+	assert.Nil(t, err)
+	assert.Len(t, summary.Warnings, 1)
+	assert.Contains(t, summary.Warnings[0].String(), "outside: points to an absolute path")
+	assert.NotContains(t, getArchiveNames(t, dir), "outside")
+
+	ignored, err := ListIgnored(dir, IgnorePatterns("outside", "settings"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outside", "settings"}, ignored)
+}
+
+func writeTree(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+	return dir
+}
+
+func getArchiveNames(t *testing.T, dir string, filters ...Filter) []string {
+	buf := &bytes.Buffer{}
+	_, err := GzipDirectory(dir, buf, filters...)
+	assert.Nil(t, err)
+
+	gr, err := gzip.NewReader(buf)
+	assert.Nil(t, err)
+	tr := tar.NewReader(gr)
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		names = append(names, header.Name)
+	}
+	return names
+}
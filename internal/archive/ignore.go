@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+const (
+	GitIgnoreFileName     = ".gitignore"
+	NucleusIgnoreFileName = ".nucleusignore"
+)
+
+type ignorePattern struct {
+	matcher *gitignore.GitIgnore
+	negate  bool
+}
+
+// The patterns of a single ignore file, which apply to the paths under the directory it lives in
+type ignoreLayer struct {
+	patterns []*ignorePattern
+}
+
+type ignoreFiles struct {
+	root      string
+	fileNames []string
+
+	mu     sync.Mutex
+	layers map[string]map[string]*ignoreLayer // keyed by file name, then directory
+}
+
+// Leaves out every path that is ignored by the ignore files with the given names.
+// Ignore files are read from every directory of the tree and use gitignore syntax, including ! negation.
+// Patterns in deeper directories override the ones above them, and the files listed later take priority over the earlier ones.
+func IgnoreFiles(root string, fileNames ...string) Filter {
+	files := &ignoreFiles{
+		root:      root,
+		fileNames: fileNames,
+		layers:    map[string]map[string]*ignoreLayer{},
+	}
+	return files.isIgnored
+}
+
+func (f *ignoreFiles) isIgnored(relPath string, isDir bool) bool {
+	dirs := getParentDirs(relPath)
+
+	ignored := false
+	for _, fileName := range f.fileNames {
+		for _, dir := range dirs {
+			layer := f.getLayer(fileName, dir)
+			if layer == nil {
+				continue
+			}
+			if matched, negate := layer.match(strings.TrimPrefix(relPath, dir)); matched {
+				ignored = !negate
+			}
+		}
+	}
+	return ignored
+}
+
+// Returns the last pattern in the layer that matches the path
+func (l *ignoreLayer) match(relPath string) (bool, bool) {
+	matched, negate := false, false
+	for _, pattern := range l.patterns {
+		if pattern.matcher.MatchesPath(relPath) {
+			matched, negate = true, pattern.negate
+		}
+	}
+	return matched, negate
+}
+
+func (f *ignoreFiles) getLayer(fileName string, dir string) *ignoreLayer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	byDir, ok := f.layers[fileName]
+	if !ok {
+		byDir = map[string]*ignoreLayer{}
+		f.layers[fileName] = byDir
+	}
+	if layer, ok := byDir[dir]; ok {
+		return layer
+	}
+
+	// missing and unreadable ignore files are treated as empty, the same way git does
+	var layer *ignoreLayer
+	data, err := os.ReadFile(filepath.Join(f.root, filepath.FromSlash(dir), fileName))
+	if err == nil {
+		layer = &ignoreLayer{patterns: compileIgnorePatterns(strings.Split(string(data), "\n"))}
+	}
+	byDir[dir] = layer
+	return layer
+}
+
+func compileIgnorePatterns(lines []string) []*ignorePattern {
+	patterns := []*ignorePattern{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// escaped \! and \# are matched literally by the compiled pattern
+		negate := strings.HasPrefix(line, "!")
+		if negate {
+			line = line[1:]
+		}
+		patterns = append(patterns, &ignorePattern{
+			matcher: gitignore.CompileIgnoreLines(line),
+			negate:  negate,
+		})
+	}
+	return patterns
+}
+
+// Returns every directory that contains the path, starting with the root ("") and ending with a slash
+func getParentDirs(relPath string) []string {
+	dirs := []string{""}
+	parent := path.Dir(strings.TrimSuffix(relPath, "/"))
+	if parent == "." {
+		return dirs
+	}
+	parts := strings.Split(parent, "/")
+	for idx := range parts {
+		dirs = append(dirs, strings.Join(parts[:idx+1], "/")+"/")
+	}
+	return dirs
+}
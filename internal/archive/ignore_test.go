@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreFiles(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":                "*.log\nbuild/\n",
+		".nucleusignore":            "# large files\ntestdata/fixtures/\n!keep.log\n",
+		"app.log":                   "log",
+		"keep.log":                  "log",
+		"main.go":                   "package main",
+		"build/out":                 "bin",
+		"sub/.gitignore":            "!debug.log\nlocal.txt\n",
+		"sub/debug.log":             "log",
+		"sub/local.txt":             "local",
+		"sub/other.log":             "log",
+		"testdata/small.txt":        "small",
+		"testdata/fixtures/big.bin": "big",
+	})
+
+	filter := IgnoreFiles(dir, GitIgnoreFileName, NucleusIgnoreFileName)
+	ignored, err := ListIgnored(dir, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app.log", "build/", "sub/local.txt", "sub/other.log", "testdata/fixtures/"}, ignored)
+
+	names := getArchiveNames(t, dir, filter)
+	assert.Equal(t, []string{".gitignore", ".nucleusignore", "keep.log", "main.go", "sub/", "sub/.gitignore", "sub/debug.log", "testdata/", "testdata/small.txt"}, names)
+}
+
+func TestIgnoreFiles_Priority(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":     "fixtures/\n",
+		".nucleusignore": "!fixtures/\n*.tmp\n",
+		"fixtures/a.txt": "a",
+		"scratch.tmp":    "tmp",
+	})
+
+	ignored, err := ListIgnored(dir, IgnoreFiles(dir, GitIgnoreFileName, NucleusIgnoreFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"scratch.tmp"}, ignored, ".nucleusignore should override .gitignore")
+
+	ignored, err = ListIgnored(dir, IgnoreFiles(dir, GitIgnoreFileName))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fixtures/"}, ignored)
+}
+
+func TestGetParentDirs(t *testing.T) {
+	assert.Equal(t, []string{""}, getParentDirs("file.txt"))
+	assert.Equal(t, []string{""}, getParentDirs("dir/"))
+	assert.Equal(t, []string{"", "a/", "a/b/"}, getParentDirs("a/b/c.txt"))
+	assert.Equal(t, []string{"", "a/"}, getParentDirs("a/b/"))
+}
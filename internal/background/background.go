@@ -0,0 +1,33 @@
+package background
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Starts the running executable again with the args, detached from the terminal and the current process,
+// so it keeps running after the current process exits. Returns the pid of the new process.
+// It inherits the environment and working directory, its stdin, stdout and stderr are discarded.
+func StartSelf(args ...string) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(executable, args...)
+	setDetached(cmd)
+	err = cmd.Start()
+	if err != nil {
+		return 0, err
+	}
+	// reaped in the background, so it doesn't linger once it exits before the current process does
+	go func() { _ = cmd.Wait() }()
+	return cmd.Process.Pid, nil
+}
+
+// Reports whether a process with the pid is running
+func IsRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return isRunning(pid)
+}
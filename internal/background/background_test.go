@@ -0,0 +1,24 @@
+package background
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRunning(t *testing.T) {
+	assert.True(t, IsRunning(os.Getpid()))
+	assert.False(t, IsRunning(0))
+	assert.False(t, IsRunning(-1))
+}
+
+func TestIsRunning_Exited(t *testing.T) {
+	executable, err := os.Executable()
+	assert.Nil(t, err)
+	// runs no tests, so it exits right away
+	cmd := exec.Command(executable, "-test.run", "^$")
+	assert.Nil(t, cmd.Run())
+	assert.False(t, IsRunning(cmd.Process.Pid))
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package background
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// A new session has no controlling terminal, so closing the terminal or Ctrl-C doesn't reach the process
+func setDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func isRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// signal 0 only checks that the process exists, EPERM means it does but belongs to another user
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
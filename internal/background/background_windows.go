@@ -0,0 +1,30 @@
+package background
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const (
+	detachedProcess = 0x00000008
+	stillActive     = 259
+)
+
+// A detached process has no console, and a new process group doesn't receive the Ctrl-C of the current one
+func setDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
+
+func isRunning(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = syscall.CloseHandle(handle) }()
+	var exitCode uint32
+	err = syscall.GetExitCodeProcess(handle, &exitCode)
+	return err == nil && exitCode == stillActive
+}
@@ -13,7 +13,15 @@ import (
 
 type NucleusConfig struct {
 	CliVersion string     `yaml:"cliVersion"`
-	Spec       SpecStruct `yaml:"spec"`
+	Spec       SpecStruct `yaml:"spec,omitempty"`
+	// Used instead of Spec when a single manifest describes multiple services
+	Services []ServiceConfig `yaml:"services,omitempty"`
+}
+
+type ServiceConfig struct {
+	// Source directory of the service, relative to the manifest
+	Directory string     `yaml:"directory"`
+	Spec      SpecStruct `yaml:"spec"`
 }
 
 type NucleusSecrets = map[string]map[string]string
@@ -38,6 +46,8 @@ type SpecStruct struct {
 	AllowedServices    []string             `yaml:"allowedServices,omitempty"`
 	DisallowedServices []string             `yaml:"disallowedServices,omitempty"`
 	Resources          ResourceRequirements `yaml:"resources,omitempty"`
+	// Per-environment overrides that are merged over the base spec at deploy time
+	Environments map[string]EnvironmentSpec `yaml:"environments,omitempty"`
 }
 
 type NucleusAuthConfig struct {
@@ -47,15 +57,59 @@ type NucleusAuthConfig struct {
 }
 
 const (
-	nucleusConfigPath = "nucleus.yaml"
-	nucleusFolderName = ".nucleus"
-	nucleusAuthName   = "auth.yaml"
+	NucleusManifestEnvKey = "NUCLEUS_MANIFEST"
+
+	defaultNucleusConfigPath = "nucleus.yaml"
+	nucleusFolderName        = ".nucleus"
+	nucleusAuthName          = "auth.yaml"
 )
 
 var (
 	ErrMustLogin = fmt.Errorf("error retrieving auth information. Try logging in via 'nucleus login'")
+
+	nucleusConfigPath = defaultNucleusConfigPath
 )
 
+// Sets the path of the nucleus manifest that is read and written by this package.
+// If the path is a directory, the default manifest name is used within it.
+// An empty path resets to the default manifest in the current directory.
+func SetNucleusConfigPath(path string) {
+	if path == "" {
+		nucleusConfigPath = defaultNucleusConfigPath
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, defaultNucleusConfigPath)
+	}
+	nucleusConfigPath = path
+}
+
+func GetNucleusConfigPath() string {
+	return nucleusConfigPath
+}
+
+// Returns the absolute path of the directory that holds the nucleus manifest
+func GetNucleusConfigDir() (string, error) {
+	absPath, err := filepath.Abs(nucleusConfigPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(absPath), nil
+}
+
+// Returns the absolute path of a service's source directory.
+// Relative directories are resolved against the directory of the nucleus manifest.
+func GetServiceDirectory(svc ServiceConfig) (string, error) {
+	if filepath.IsAbs(svc.Directory) {
+		return filepath.Clean(svc.Directory), nil
+	}
+	configDir, err := GetNucleusConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, svc.Directory), nil
+}
+
 func DoesNucleusConfigExist() bool {
 	_, err := os.Stat(nucleusConfigPath)
 	return !errors.Is(err, os.ErrNotExist)
@@ -75,17 +129,28 @@ func GetNucleusConfig() (*NucleusConfig, error) {
 		return nil, err
 	}
 
+	err = CheckCliVersion(yamlData.CliVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	return &yamlData, nil
 }
 
-// Sets the nucleus config defined by the user
+// Sets the nucleus config defined by the user.
+// Comments, key order and formatting of an existing manifest are kept for every value that did not change.
 func SetNucleusConfig(config *NucleusConfig) error {
-	yamlData, err := yaml.Marshal(&config)
+	existing, err := os.ReadFile(nucleusConfigPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	yamlData, err := renderNucleusConfig(existing, config)
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(nucleusConfigPath, yamlData, 0644)
+	err = writeFileAtomic(nucleusConfigPath, yamlData, 0644)
 	if err != nil {
 		return fmt.Errorf("Unable to write data into the config file")
 	}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetNucleusConfigPath(t *testing.T) {
+	defer SetNucleusConfigPath("")
+
+	SetNucleusConfigPath("./foo/bar.yaml")
+	assert.Equal(t, "./foo/bar.yaml", GetNucleusConfigPath())
+
+	dir := t.TempDir()
+	SetNucleusConfigPath(dir)
+	assert.Equal(t, filepath.Join(dir, "nucleus.yaml"), GetNucleusConfigPath(), "should use the default manifest name within a directory")
+
+	configDir, err := GetNucleusConfigDir()
+	assert.Nil(t, err)
+	assert.Equal(t, dir, configDir)
+
+	SetNucleusConfigPath("")
+	assert.Equal(t, "nucleus.yaml", GetNucleusConfigPath())
+
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	configDir, err = GetNucleusConfigDir()
+	assert.Nil(t, err)
+	assert.Equal(t, wd, configDir)
+}
+
+func TestGetServiceDirectory(t *testing.T) {
+	defer SetNucleusConfigPath("")
+
+	dir := t.TempDir()
+	SetNucleusConfigPath(filepath.Join(dir, "nucleus.yaml"))
+
+	svcDir, err := GetServiceDirectory(ServiceConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, dir, svcDir)
+
+	svcDir, err = GetServiceDirectory(ServiceConfig{Directory: "./services/api"})
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "services", "api"), svcDir)
+
+	absDir := filepath.Join(dir, "other")
+	svcDir, err = GetServiceDirectory(ServiceConfig{Directory: absDir})
+	assert.Nil(t, err)
+	assert.Equal(t, absDir, svcDir)
+}
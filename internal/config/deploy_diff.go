@@ -0,0 +1,118 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of change between two deploy configs
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// DeployConfigChange is a single difference between the config of two deploys.
+// The values of sensitive changes are left empty, since they are the encrypted secrets.
+type DeployConfigChange struct {
+	Field     string
+	Kind      string
+	Old       string
+	New       string
+	Sensitive bool
+}
+
+// Returns every difference between the config of two deploys, sorted by field.
+// A nil before deploy is treated as having no config at all, so everything in the after deploy is added.
+func DiffDeployConfig(before *DeployHistoryEntry, after *DeployHistoryEntry) []*DeployConfigChange {
+	if before == nil {
+		before = &DeployHistoryEntry{}
+	}
+	if after == nil {
+		after = &DeployHistoryEntry{}
+	}
+	changes := []*DeployConfigChange{}
+	changes = appendValueChange(changes, "image", before.Image, after.Image)
+	changes = appendValueChange(changes, "code", before.UploadKey, after.UploadKey)
+	changes = appendValueChange(changes, "isPrivate", formatBool(before.IsPrivate), formatBool(after.IsPrivate))
+	changes = appendMapChanges(changes, "vars", before.Vars, after.Vars, false)
+	changes = appendMapChanges(changes, "secrets", before.Secrets, after.Secrets, true)
+	changes = appendMapChanges(changes, "buildtimeVars", before.BuildtimeVars, after.BuildtimeVars, false)
+	changes = appendMapChanges(changes, "resources", getResourceValues(before.Resources), getResourceValues(after.Resources), false)
+	changes = appendValueChange(changes, "allowedServices", strings.Join(before.AllowedServices, ","), strings.Join(after.AllowedServices, ","))
+	changes = appendValueChange(changes, "disallowedServices", strings.Join(before.DisallowedServices, ","), strings.Join(after.DisallowedServices, ","))
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+func appendValueChange(changes []*DeployConfigChange, field string, before string, after string) []*DeployConfigChange {
+	switch {
+	case before == after:
+		return changes
+	case before == "":
+		return append(changes, &DeployConfigChange{Field: field, Kind: ChangeAdded, New: after})
+	case after == "":
+		return append(changes, &DeployConfigChange{Field: field, Kind: ChangeRemoved, Old: before})
+	}
+	return append(changes, &DeployConfigChange{Field: field, Kind: ChangeChanged, Old: before, New: after})
+}
+
+func appendMapChanges(
+	changes []*DeployConfigChange,
+	field string,
+	before map[string]string,
+	after map[string]string,
+	sensitive bool,
+) []*DeployConfigChange {
+	for key, beforeValue := range before {
+		afterValue, ok := after[key]
+		change := &DeployConfigChange{Field: field + "." + key, Sensitive: sensitive}
+		if !ok {
+			change.Kind = ChangeRemoved
+		} else if beforeValue != afterValue {
+			change.Kind = ChangeChanged
+		} else {
+			continue
+		}
+		if !sensitive {
+			change.Old = beforeValue
+			change.New = afterValue
+		}
+		changes = append(changes, change)
+	}
+	for key, afterValue := range after {
+		if _, ok := before[key]; ok {
+			continue
+		}
+		change := &DeployConfigChange{Field: field + "." + key, Kind: ChangeAdded, Sensitive: sensitive}
+		if !sensitive {
+			change.New = afterValue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func getResourceValues(resources ResourceRequirements) map[string]string {
+	values := map[string]string{
+		"minimum.cpu":    resources.Minimum.Cpu,
+		"minimum.memory": resources.Minimum.Memory,
+		"maximum.cpu":    resources.Maximum.Cpu,
+		"maximum.memory": resources.Maximum.Memory,
+	}
+	for key, value := range values {
+		if value == "" {
+			delete(values, key)
+		}
+	}
+	return values
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDeployConfig(t *testing.T) {
+	before := &DeployHistoryEntry{
+		UploadKey: "key-1",
+		Vars:      map[string]string{"FOO": "staging", "REMOVED": "x", "SAME": "y"},
+		Secrets:   map[string]string{"TOKEN": "cipher-1", "OLD": "cipher-2"},
+		Resources: ResourceRequirements{
+			Maximum: ResourceList{Cpu: "1", Memory: "512Mi"},
+		},
+		AllowedServices: []string{"web"},
+	}
+	after := &DeployHistoryEntry{
+		UploadKey: "key-1",
+		IsPrivate: true,
+		Vars:      map[string]string{"FOO": "prod", "SAME": "y", "ADDED": "z"},
+		Secrets:   map[string]string{"TOKEN": "cipher-3", "NEW": "cipher-4"},
+		Resources: ResourceRequirements{
+			Maximum: ResourceList{Cpu: "2", Memory: "512Mi"},
+		},
+		AllowedServices: []string{"web"},
+	}
+
+	assert.Equal(t, []*DeployConfigChange{
+		{Field: "isPrivate", Kind: ChangeChanged, Old: "false", New: "true"},
+		{Field: "resources.maximum.cpu", Kind: ChangeChanged, Old: "1", New: "2"},
+		{Field: "secrets.NEW", Kind: ChangeAdded, Sensitive: true},
+		{Field: "secrets.OLD", Kind: ChangeRemoved, Sensitive: true},
+		{Field: "secrets.TOKEN", Kind: ChangeChanged, Sensitive: true},
+		{Field: "vars.ADDED", Kind: ChangeAdded, New: "z"},
+		{Field: "vars.FOO", Kind: ChangeChanged, Old: "staging", New: "prod"},
+		{Field: "vars.REMOVED", Kind: ChangeRemoved, Old: "x"},
+	}, DiffDeployConfig(before, after))
+
+	assert.Empty(t, DiffDeployConfig(after, after))
+}
+
+func TestDiffDeployConfig_NoPreviousDeploy(t *testing.T) {
+	changes := DiffDeployConfig(nil, &DeployHistoryEntry{
+		Image: "nginx:latest",
+		Vars:  map[string]string{"FOO": "bar"},
+	})
+	assert.Equal(t, []*DeployConfigChange{
+		{Field: "image", Kind: ChangeAdded, New: "nginx:latest"},
+		{Field: "vars.FOO", Kind: ChangeAdded, New: "bar"},
+	}, changes)
+}
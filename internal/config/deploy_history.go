@@ -0,0 +1,230 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	nucleusDeployHistoryName = "deploys.yaml"
+	// holds the logs of the deploys that were handed to a background process
+	nucleusDeployLogsDirName = "deploys"
+
+	// only the most recent deploys of every service to an environment are kept
+	deployHistoryLimit = 20
+)
+
+// Outcomes of a deploy as far as the CLI knows
+const (
+	DeployOutcomeDeployed  = "deployed"
+	DeployOutcomeFailed    = "failed"
+	DeployOutcomeCancelled = "cancelled"
+	// the CLI stopped watching before the deploy finished
+	DeployOutcomeRunning = "running"
+	DeployOutcomeUnknown = "unknown"
+)
+
+// DeployHistoryEntry records a deploy started from this machine, with everything needed to deploy its artifact again
+type DeployHistoryEntry struct {
+	Id              string    `yaml:"id"`
+	ApiEnv          string    `yaml:"apiEnv"`
+	EnvironmentName string    `yaml:"environmentName"`
+	ServiceName     string    `yaml:"serviceName"`
+	DeployedAt      time.Time `yaml:"deployedAt"`
+	PipelineRun     string    `yaml:"pipelineRun,omitempty"`
+	GitSha          string    `yaml:"gitSha,omitempty"`
+	Outcome         string    `yaml:"outcome"`
+	Url             string    `yaml:"url,omitempty"`
+	// set when a background process follows the deploy, see GetDeployLogPath
+	FollowerPid int `yaml:"followerPid,omitempty"`
+
+	ServiceType        string               `yaml:"serviceType"`
+	UploadKey          string               `yaml:"uploadKey,omitempty"`
+	Hash               string               `yaml:"hash,omitempty"`
+	Image              string               `yaml:"image,omitempty"`
+	IsPrivate          bool                 `yaml:"isPrivate"`
+	Vars               map[string]string    `yaml:"vars,omitempty"`
+	Secrets            map[string]string    `yaml:"secrets,omitempty"`
+	BuildtimeVars      map[string]string    `yaml:"buildtimeVars,omitempty"`
+	Resources          ResourceRequirements `yaml:"resources"`
+	AllowedServices    []string             `yaml:"allowedServices,omitempty"`
+	DisallowedServices []string             `yaml:"disallowedServices,omitempty"`
+}
+
+type deployHistory struct {
+	Deploys []*DeployHistoryEntry `yaml:"deploys"`
+}
+
+var (
+	// services are deployed concurrently, so updates to the history file must not interleave
+	deployHistoryMu sync.Mutex
+)
+
+// Returns a new id to record a deploy under
+func NewDeployId() (string, error) {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Adds the deploy to the history, or updates it if a deploy with the same id was already recorded.
+// Older deploys of the same service to the same environment are dropped once there are too many.
+func RecordDeploy(entry *DeployHistoryEntry) error {
+	deployHistoryMu.Lock()
+	defer deployHistoryMu.Unlock()
+
+	history, err := readDeployHistory()
+	if err != nil {
+		// the history is what rollbacks are made from, so a file that can't be read is never replaced
+		return fmt.Errorf("unable to read the deploy history, leaving it as is: %w", err)
+	}
+	replaced := false
+	for idx, existing := range history.Deploys {
+		if existing.Id == entry.Id {
+			history.Deploys[idx] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		history.Deploys = append(history.Deploys, entry)
+	}
+
+	deploys := []*DeployHistoryEntry{}
+	dropped := []string{}
+	numKept := 0
+	// newest first, so the oldest deploys of the service are the ones dropped
+	for idx := len(history.Deploys) - 1; idx >= 0; idx-- {
+		existing := history.Deploys[idx]
+		if existing.ApiEnv == entry.ApiEnv && existing.EnvironmentName == entry.EnvironmentName && existing.ServiceName == entry.ServiceName {
+			if numKept == deployHistoryLimit {
+				dropped = append(dropped, existing.Id)
+				continue
+			}
+			numKept++
+		}
+		deploys = append([]*DeployHistoryEntry{existing}, deploys...)
+	}
+	history.Deploys = deploys
+	err = writeDeployHistory(history)
+	if err != nil {
+		return err
+	}
+	for _, id := range dropped {
+		removeDeployLog(id)
+	}
+	return nil
+}
+
+// Returns the recorded deploys of the service to the environment, newest first
+func GetDeployHistory(apiEnv string, environmentName string, serviceName string) ([]*DeployHistoryEntry, error) {
+	deployHistoryMu.Lock()
+	defer deployHistoryMu.Unlock()
+
+	history, err := readDeployHistory()
+	if err != nil {
+		return nil, err
+	}
+	deploys := []*DeployHistoryEntry{}
+	for _, entry := range history.Deploys {
+		if entry.ApiEnv == apiEnv && entry.EnvironmentName == environmentName && entry.ServiceName == serviceName {
+			deploys = append(deploys, entry)
+		}
+	}
+	sort.SliceStable(deploys, func(i, j int) bool {
+		return deploys[i].DeployedAt.After(deploys[j].DeployedAt)
+	})
+	return deploys, nil
+}
+
+// Returns the recorded deploy with the id, or the deploy that ran in the pipeline run
+func GetDeploy(apiEnv string, id string) (*DeployHistoryEntry, error) {
+	deployHistoryMu.Lock()
+	defer deployHistoryMu.Unlock()
+
+	history, err := readDeployHistory()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range history.Deploys {
+		if entry.ApiEnv == apiEnv && (entry.Id == id || entry.PipelineRun == id) {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("no deploy with id or pipeline run %s was found in the deploy history", id)
+}
+
+// Returns the path of the log that the background process following the deploy writes to.
+// Logs are removed along with their deploy once it is dropped from the history.
+func GetDeployLogPath(id string) (string, error) {
+	dirPath, err := GetOrCreateNucleusFolder()
+	if err != nil {
+		return "", err
+	}
+	logsPath := filepath.Join(dirPath, nucleusDeployLogsDirName)
+	err = os.MkdirAll(logsPath, 0700)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(logsPath, id+".log"), nil
+}
+
+func removeDeployLog(id string) {
+	path, err := GetDeployLogPath(id)
+	if err != nil {
+		return
+	}
+	// most deploys were never followed in the background, so there is nothing to remove
+	_ = os.Remove(path)
+}
+
+func getDeployHistoryPath() (string, error) {
+	dirPath, err := GetOrCreateNucleusFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirPath, nucleusDeployHistoryName), nil
+}
+
+func readDeployHistory() (*deployHistory, error) {
+	path, err := getDeployHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &deployHistory{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	history := &deployHistory{}
+	err = yaml.Unmarshal(data, history)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func writeDeployHistory(history *deployHistory) error {
+	path, err := getDeployHistoryPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return err
+	}
+	// resolved vars can hold values from the local environment, so the file is only readable by the user
+	return writeFileAtomic(path, data, 0600)
+}
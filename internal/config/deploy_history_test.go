@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployHistory(t *testing.T) {
+	t.Setenv("NUCLEUS_CONFIG_DIR", t.TempDir())
+
+	deploys, err := GetDeployHistory("prod", "dev", "api")
+	assert.Nil(t, err)
+	assert.Empty(t, deploys)
+
+	now := time.Now().UTC()
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "a", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "api", DeployedAt: now.Add(-time.Hour), Outcome: DeployOutcomeDeployed}))
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "b", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "api", DeployedAt: now, Outcome: DeployOutcomeRunning}))
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "c", ApiEnv: "prod", EnvironmentName: "prod", ServiceName: "api", DeployedAt: now}))
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "d", ApiEnv: "stage", EnvironmentName: "dev", ServiceName: "api", DeployedAt: now}))
+
+	deploys, err = GetDeployHistory("prod", "dev", "api")
+	assert.Nil(t, err)
+	assert.Len(t, deploys, 2)
+	assert.Equal(t, "b", deploys[0].Id, "newest deploy should be first")
+	assert.Equal(t, "a", deploys[1].Id)
+
+	// recording a deploy again updates it
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "b", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "api", DeployedAt: now, Outcome: DeployOutcomeDeployed}))
+	entry, err := GetDeploy("prod", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, DeployOutcomeDeployed, entry.Outcome)
+	deploys, err = GetDeployHistory("prod", "dev", "api")
+	assert.Nil(t, err)
+	assert.Len(t, deploys, 2)
+
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "e", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "web", DeployedAt: now, PipelineRun: "web-run-1"}))
+	entry, err = GetDeploy("prod", "web-run-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "e", entry.Id, "deploys should be found by their pipeline run")
+
+	_, err = GetDeploy("prod", "d")
+	assert.Error(t, err, "deploys should not be shared across api environments")
+	_, err = GetDeploy("prod", "missing")
+	assert.Error(t, err)
+}
+
+func TestDeployHistory_Limit(t *testing.T) {
+	t.Setenv("NUCLEUS_CONFIG_DIR", t.TempDir())
+
+	start := time.Now().UTC()
+	logPath, err := GetDeployLogPath("deploy-0")
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(logPath, []byte{}, 0600))
+	assert.Nil(t, RecordDeploy(&DeployHistoryEntry{Id: "other", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "web", DeployedAt: start}))
+	for idx := 0; idx < deployHistoryLimit+5; idx++ {
+		assert.Nil(t, RecordDeploy(&DeployHistoryEntry{
+			Id:              fmt.Sprintf("deploy-%d", idx),
+			ApiEnv:          "prod",
+			EnvironmentName: "dev",
+			ServiceName:     "api",
+			DeployedAt:      start.Add(time.Duration(idx) * time.Minute),
+		}))
+	}
+
+	deploys, err := GetDeployHistory("prod", "dev", "api")
+	assert.Nil(t, err)
+	assert.Len(t, deploys, deployHistoryLimit)
+	assert.Equal(t, fmt.Sprintf("deploy-%d", deployHistoryLimit+4), deploys[0].Id)
+	assert.Equal(t, "deploy-5", deploys[len(deploys)-1].Id)
+
+	_, err = GetDeploy("prod", "other")
+	assert.Nil(t, err, "other services should keep their deploys")
+	_, err = os.Stat(logPath)
+	assert.True(t, os.IsNotExist(err), "the log of a dropped deploy should be removed")
+}
+
+func TestNewDeployId(t *testing.T) {
+	id, err := NewDeployId()
+	assert.Nil(t, err)
+	assert.Len(t, id, 8)
+	other, err := NewDeployId()
+	assert.Nil(t, err)
+	assert.NotEqual(t, id, other)
+}
+
+func TestDeployHistory_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NUCLEUS_CONFIG_DIR", dir)
+
+	path := filepath.Join(dir, nucleusDeployHistoryName)
+	assert.Nil(t, os.WriteFile(path, []byte("deploys: ["), 0600))
+
+	err := RecordDeploy(&DeployHistoryEntry{Id: "a", ApiEnv: "prod", EnvironmentName: "dev", ServiceName: "api"})
+	assert.Error(t, err)
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "deploys: [", string(data), "a history that can't be read should not be overwritten")
+}
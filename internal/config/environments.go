@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+)
+
+// EnvironmentSpec holds the values of a spec that may be overridden for a single environment.
+// Unset fields fall back to the values of the base spec.
+type EnvironmentSpec struct {
+	IsPrivate *bool                `yaml:"isPrivate,omitempty"`
+	Vars      map[string]string    `yaml:"vars,omitempty"`
+	Resources ResourceRequirements `yaml:"resources,omitempty"`
+	// Changes to a protected environment must be confirmed, such as promoting a deploy to it
+	Protected bool `yaml:"protected,omitempty"`
+}
+
+// Returns the spec that should be used when deploying to the given environment.
+// The environment overrides are deep merged over the base spec and the result no longer contains any overrides.
+// The provided spec is not modified.
+func GetSpecForEnv(spec *SpecStruct, envName string) *SpecStruct {
+	if spec == nil {
+		return &SpecStruct{}
+	}
+	output := *spec
+	output.Vars = mergeVars(spec.Vars, nil)
+	output.Environments = nil
+
+	overrides, ok := getEnvironmentSpec(spec, envName)
+	if !ok {
+		return &output
+	}
+
+	if overrides.IsPrivate != nil {
+		output.IsPrivate = *overrides.IsPrivate
+	}
+	output.Vars = mergeVars(output.Vars, overrides.Vars)
+	output.Resources = mergeResources(output.Resources, overrides.Resources)
+	return &output
+}
+
+// Returns true if the spec marks the environment as protected
+func IsProtectedEnv(spec *SpecStruct, envName string) bool {
+	if spec == nil {
+		return false
+	}
+	envSpec, ok := getEnvironmentSpec(spec, envName)
+	return ok && envSpec.Protected
+}
+
+func getEnvironmentSpec(spec *SpecStruct, envName string) (EnvironmentSpec, bool) {
+	envName = strings.ToLower(envName)
+	for name, envSpec := range spec.Environments {
+		if strings.ToLower(name) == envName {
+			return envSpec, true
+		}
+	}
+	return EnvironmentSpec{}, false
+}
+
+func mergeVars(base map[string]string, overrides map[string]string) map[string]string {
+	if base == nil && overrides == nil {
+		return nil
+	}
+	output := map[string]string{}
+	for key, value := range base {
+		output[key] = value
+	}
+	for key, value := range overrides {
+		output[key] = value
+	}
+	return output
+}
+
+func mergeResources(base ResourceRequirements, overrides ResourceRequirements) ResourceRequirements {
+	return ResourceRequirements{
+		Minimum: mergeResourceList(base.Minimum, overrides.Minimum),
+		Maximum: mergeResourceList(base.Maximum, overrides.Maximum),
+	}
+}
+
+func mergeResourceList(base ResourceList, overrides ResourceList) ResourceList {
+	output := base
+	if overrides.Cpu != "" {
+		output.Cpu = overrides.Cpu
+	}
+	if overrides.Memory != "" {
+		output.Memory = overrides.Memory
+	}
+	return output
+}
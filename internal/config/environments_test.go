@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSpecForEnv(t *testing.T) {
+	isPrivate := true
+	spec := &SpecStruct{
+		ServiceName:    "foo",
+		ServiceRunTime: "go",
+		Vars: map[string]string{
+			"FOO": "bar",
+			"BAZ": "qux",
+		},
+		Resources: ResourceRequirements{
+			Minimum: ResourceList{Cpu: "100m", Memory: "128Mi"},
+			Maximum: ResourceList{Cpu: "1", Memory: "512Mi"},
+		},
+		Environments: map[string]EnvironmentSpec{
+			"Prod": {
+				IsPrivate: &isPrivate,
+				Vars: map[string]string{
+					"FOO": "prod",
+				},
+				Resources: ResourceRequirements{
+					Maximum: ResourceList{Memory: "2Gi"},
+				},
+			},
+		},
+	}
+
+	prod := GetSpecForEnv(spec, "prod")
+	assert.Equal(t, &SpecStruct{
+		ServiceName:    "foo",
+		ServiceRunTime: "go",
+		IsPrivate:      true,
+		Vars: map[string]string{
+			"FOO": "prod",
+			"BAZ": "qux",
+		},
+		Resources: ResourceRequirements{
+			Minimum: ResourceList{Cpu: "100m", Memory: "128Mi"},
+			Maximum: ResourceList{Cpu: "1", Memory: "2Gi"},
+		},
+	}, prod)
+
+	stage := GetSpecForEnv(spec, "stage")
+	assert.False(t, stage.IsPrivate)
+	assert.Equal(t, map[string]string{"FOO": "bar", "BAZ": "qux"}, stage.Vars)
+	assert.Equal(t, spec.Resources, stage.Resources)
+	assert.Nil(t, stage.Environments)
+
+	assert.Equal(t, "bar", spec.Vars["FOO"], "base spec should not be modified")
+	assert.NotNil(t, spec.Environments)
+
+	assert.Equal(t, &SpecStruct{}, GetSpecForEnv(nil, "prod"))
+}
+
+func TestIsProtectedEnv(t *testing.T) {
+	spec := &SpecStruct{
+		Environments: map[string]EnvironmentSpec{
+			"Prod":  {Protected: true},
+			"stage": {},
+		},
+	}
+	assert.True(t, IsProtectedEnv(spec, "prod"))
+	assert.False(t, IsProtectedEnv(spec, "stage"))
+	assert.False(t, IsProtectedEnv(spec, "dev"))
+	assert.False(t, IsProtectedEnv(nil, "prod"))
+}
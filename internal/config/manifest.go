@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	manifestIndent = 2
+)
+
+// Renders the config as yaml on top of the existing manifest contents.
+// Only the values that changed are rewritten, so comments and key order of the existing manifest are kept.
+// If there is no existing manifest, the config is rendered as is.
+func renderNucleusConfig(existing []byte, config *NucleusConfig) ([]byte, error) {
+	updated := &yaml.Node{}
+	err := updated.Encode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		err = yaml.Unmarshal(existing, doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 {
+		mergeYamlNode(doc.Content[0], updated, reflect.TypeOf(*config))
+	} else {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{updated}}
+	}
+
+	return encodeYamlDocument(doc)
+}
+
+func encodeYamlDocument(doc *yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(manifestIndent)
+	err := encoder.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Updates the existing node in place so that it holds the same values as the updated node,
+// while leaving untouched values (and their comments and styles) as they were.
+// The type is what the node is read into, which tells apart the keys that were removed from the ones nucleus doesn't know.
+func mergeYamlNode(existing *yaml.Node, updated *yaml.Node, typ reflect.Type) {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if existing.Kind != updated.Kind {
+		replaceYamlNode(existing, updated)
+		return
+	}
+
+	switch existing.Kind {
+	case yaml.MappingNode:
+		mergeYamlMapping(existing, updated, typ)
+	case yaml.SequenceNode:
+		mergeYamlSequence(existing, updated, getYamlElemType(typ))
+	case yaml.ScalarNode:
+		if !isSameYamlScalar(existing, updated, typ) {
+			style := existing.Style
+			replaceYamlNode(existing, updated)
+			if existing.ShortTag() == "!!str" && updated.Style == 0 {
+				// keep the quoting style the user chose for the string
+				existing.Style = style
+			}
+		}
+	default:
+		replaceYamlNode(existing, updated)
+	}
+}
+
+func mergeYamlMapping(existing *yaml.Node, updated *yaml.Node, typ reflect.Type) {
+	fields := getYamlFields(typ)
+	updatedKeys := map[string]struct{}{}
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key, value := updated.Content[i], updated.Content[i+1]
+		updatedKeys[key.Value] = struct{}{}
+
+		existingValue := getYamlMappingValue(existing, key.Value)
+		if existingValue != nil {
+			valueType := getYamlElemType(typ)
+			if fields != nil {
+				valueType = fields[key.Value]
+			}
+			mergeYamlNode(existingValue, value, valueType)
+		} else {
+			existing.Content = append(existing.Content, key, value)
+		}
+	}
+
+	// drop the keys that are no longer present, keys that aren't part of the config are kept as they are
+	content := []*yaml.Node{}
+	for i := 0; i+1 < len(existing.Content); i += 2 {
+		key := existing.Content[i].Value
+		_, isUpdated := updatedKeys[key]
+		_, isField := fields[key]
+		if isUpdated || (fields != nil && !isField) {
+			content = append(content, existing.Content[i], existing.Content[i+1])
+		}
+	}
+	existing.Content = content
+}
+
+// Lists the items in the order of the updated sequence, reusing the existing item each one matches
+// so that its comments and styles are kept. Items that are no longer present are dropped.
+func mergeYamlSequence(existing *yaml.Node, updated *yaml.Node, elemType reflect.Type) {
+	used := make([]bool, len(existing.Content))
+	content := []*yaml.Node{}
+	for idx, item := range updated.Content {
+		match := findYamlItem(existing.Content, used, idx, item, elemType)
+		if match == -1 {
+			content = append(content, item)
+			continue
+		}
+		used[match] = true
+		mergeYamlNode(existing.Content[match], item, elemType)
+		content = append(content, existing.Content[match])
+	}
+	existing.Content = content
+}
+
+// Returns the index of the unused existing item that the updated item stands for, or -1 if there is none.
+// Scalars match by value and services by name, anything else only matches the item at the same index.
+func findYamlItem(items []*yaml.Node, used []bool, idx int, item *yaml.Node, elemType reflect.Type) int {
+	name := getYamlItemName(item)
+	for i, existing := range items {
+		if used[i] || existing.Kind != item.Kind {
+			continue
+		}
+		switch {
+		case item.Kind == yaml.ScalarNode:
+			if isSameYamlScalar(existing, item, elemType) {
+				return i
+			}
+		case name != "":
+			if getYamlItemName(existing) == name {
+				return i
+			}
+		case i == idx && getYamlItemName(existing) == "":
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the service name of an item of the services list
+func getYamlItemName(node *yaml.Node) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	spec := getYamlMappingValue(node, "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return ""
+	}
+	name := getYamlMappingValue(spec, "serviceName")
+	if name == nil || name.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return name.Value
+}
+
+// Reports whether both scalars are read as the same value, such as 1 and "1" for a string or no and false for a bool
+func isSameYamlScalar(existing *yaml.Node, updated *yaml.Node, typ reflect.Type) bool {
+	if existing.Value == updated.Value && existing.ShortTag() == updated.ShortTag() {
+		return true
+	}
+	if typ == nil || typ.Kind() == reflect.Interface {
+		return false
+	}
+	existingValue, updatedValue := reflect.New(typ), reflect.New(typ)
+	if existing.Decode(existingValue.Interface()) != nil || updated.Decode(updatedValue.Interface()) != nil {
+		return false
+	}
+	return reflect.DeepEqual(existingValue.Elem().Interface(), updatedValue.Elem().Interface())
+}
+
+// Returns the type of the fields of a struct by their yaml key, or nil if the type isn't a struct
+func getYamlFields(typ reflect.Type) map[string]reflect.Type {
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// Returns the type of the values of a map or the items of a slice, or nil for any other type
+func getYamlElemType(typ reflect.Type) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return typ.Elem()
+	}
+	return nil
+}
+
+func getYamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func replaceYamlNode(existing *yaml.Node, updated *yaml.Node) {
+	headComment, lineComment, footComment := existing.HeadComment, existing.LineComment, existing.FootComment
+	*existing = *updated
+	existing.HeadComment = headComment
+	existing.LineComment = lineComment
+	existing.FootComment = footComment
+}
+
+// Writes the file to a temp file in the same directory and renames it over the destination,
+// so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	fd, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpName := fd.Name()
+	defer os.Remove(tmpName)
+
+	_, err = fd.Write(data)
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	err = fd.Sync()
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	err = fd.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmpName, perm)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
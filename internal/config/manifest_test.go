@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	commentedManifest = `# the manifest for my service
+cliVersion: nucleus-cli/v1alpha1
+spec:
+  serviceRuntime: go # keep this on go
+  serviceName: "my-service"
+  isPrivate: false
+  # plaintext vars
+  vars:
+    ZED: "1"
+    ALPHA: two
+  allowedServices:
+    - foo
+`
+)
+
+func TestRenderNucleusConfig(t *testing.T) {
+	cfg := &NucleusConfig{
+		CliVersion: "nucleus-cli/v1alpha1",
+		Spec: SpecStruct{
+			ServiceName:     "my-service",
+			ServiceRunTime:  "go",
+			Vars:            map[string]string{"ZED": "1", "ALPHA": "three", "BETA": "new"},
+			AllowedServices: []string{"foo", "bar"},
+		},
+	}
+
+	output, err := renderNucleusConfig([]byte(commentedManifest), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, `# the manifest for my service
+cliVersion: nucleus-cli/v1alpha1
+spec:
+  serviceRuntime: go # keep this on go
+  serviceName: "my-service"
+  isPrivate: false
+  # plaintext vars
+  vars:
+    ZED: "1"
+    ALPHA: three
+    BETA: new
+  allowedServices:
+    - foo
+    - bar
+`, string(output))
+}
+
+func TestRenderNucleusConfig_RemovedValues(t *testing.T) {
+	cfg := &NucleusConfig{
+		CliVersion: "nucleus-cli/v1alpha1",
+		Spec: SpecStruct{
+			ServiceName:    "my-service",
+			ServiceRunTime: "go",
+			Vars:           map[string]string{"ALPHA": "two"},
+		},
+	}
+
+	output, err := renderNucleusConfig([]byte(commentedManifest), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, `# the manifest for my service
+cliVersion: nucleus-cli/v1alpha1
+spec:
+  serviceRuntime: go # keep this on go
+  serviceName: "my-service"
+  isPrivate: false
+  # plaintext vars
+  vars:
+    ALPHA: two
+`, string(output))
+}
+
+func TestRenderNucleusConfig_NoExistingManifest(t *testing.T) {
+	output, err := renderNucleusConfig(nil, &NucleusConfig{
+		CliVersion: "nucleus-cli/v1alpha1",
+		Spec: SpecStruct{
+			ServiceName:    "my-service",
+			ServiceRunTime: "go",
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `cliVersion: nucleus-cli/v1alpha1
+spec:
+  serviceName: my-service
+  serviceRuntime: go
+  isPrivate: false
+`, string(output))
+}
+
+func TestRenderNucleusConfig_Unchanged(t *testing.T) {
+	manifest := `cliVersion: nucleus-cli/v1alpha1
+custom: keepme
+spec:
+  serviceName: my-service
+  serviceRuntime: go
+  isPrivate: no
+  vars:
+    A: 1
+  resources:
+    minimum:
+      cpu: 1
+      extra: true # not part of the config
+`
+	cfg := &NucleusConfig{}
+	assert.Nil(t, yaml.Unmarshal([]byte(manifest), cfg))
+
+	output, err := renderNucleusConfig([]byte(manifest), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, manifest, string(output), "values that read the same should not be rewritten")
+
+	cfg.Spec.Vars["A"] = "2"
+	cfg.Spec.IsPrivate = true
+	output, err = renderNucleusConfig([]byte(manifest), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, `cliVersion: nucleus-cli/v1alpha1
+custom: keepme
+spec:
+  serviceName: my-service
+  serviceRuntime: go
+  isPrivate: true
+  vars:
+    A: "2"
+  resources:
+    minimum:
+      cpu: 1
+      extra: true # not part of the config
+`, string(output))
+}
+
+func TestRenderNucleusConfig_Sequences(t *testing.T) {
+	manifest := `cliVersion: nucleus-cli/v1alpha1
+services:
+  # the api
+  - directory: ./api
+    spec:
+      serviceName: api
+      serviceRuntime: go
+      isPrivate: false
+  # the web app
+  - directory: ./web
+    spec:
+      serviceName: web
+      serviceRuntime: nodejs
+      isPrivate: false
+      allowedServices:
+        - api # the api
+        - auth # the auth service
+`
+	cfg := &NucleusConfig{}
+	assert.Nil(t, yaml.Unmarshal([]byte(manifest), cfg))
+	cfg.Services = cfg.Services[1:]
+	cfg.Services[0].Spec.AllowedServices = []string{"auth", "billing"}
+
+	output, err := renderNucleusConfig([]byte(manifest), cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, `cliVersion: nucleus-cli/v1alpha1
+services:
+  # the web app
+  - directory: ./web
+    spec:
+      serviceName: web
+      serviceRuntime: nodejs
+      isPrivate: false
+      allowedServices:
+        - auth # the auth service
+        - billing
+`, string(output), "items should be matched by value and services by name, not by index")
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nucleus.yaml")
+
+	assert.Nil(t, os.WriteFile(path, []byte("old"), 0600))
+	assert.Nil(t, writeFileAtomic(path, []byte("new"), 0644))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", string(data))
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "should keep the existing file mode")
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1, "should not leave temp files behind")
+}
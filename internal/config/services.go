@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Returns every service defined in the nucleus config.
+// A config with a single top level spec is returned as one service that lives in the manifest's directory.
+func GetServiceConfigs(cfg *NucleusConfig) ([]ServiceConfig, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("nucleus config was nil")
+	}
+	if len(cfg.Services) == 0 {
+		return []ServiceConfig{{Directory: "", Spec: cfg.Spec}}, nil
+	}
+	// anything set in the top level spec would be silently ignored
+	if !reflect.DeepEqual(cfg.Spec, SpecStruct{}) {
+		return nil, fmt.Errorf("nucleus config must provide either spec or services, not both")
+	}
+
+	seen := map[string]struct{}{}
+	for _, svc := range cfg.Services {
+		if _, ok := seen[svc.Spec.ServiceName]; ok {
+			return nil, fmt.Errorf("service '%s' is defined more than once", svc.Spec.ServiceName)
+		}
+		seen[svc.Spec.ServiceName] = struct{}{}
+	}
+	return cfg.Services, nil
+}
+
+// Returns the services that match the provided names, in the order they were requested.
+// If no names are provided, the config must only contain a single service.
+func SelectServiceConfigs(services []ServiceConfig, serviceNames []string) ([]ServiceConfig, error) {
+	if len(serviceNames) == 0 {
+		if len(services) != 1 {
+			return nil, fmt.Errorf("nucleus config defines %d services, must select which ones to use", len(services))
+		}
+		return services, nil
+	}
+
+	byName := map[string]ServiceConfig{}
+	for _, svc := range services {
+		byName[svc.Spec.ServiceName] = svc
+	}
+
+	output := []ServiceConfig{}
+	selected := map[string]struct{}{}
+	for _, name := range serviceNames {
+		name = strings.TrimSpace(name)
+		if _, ok := selected[name]; ok {
+			continue
+		}
+		svc, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("service '%s' is not defined in the nucleus config", name)
+		}
+		selected[name] = struct{}{}
+		output = append(output, svc)
+	}
+	return output, nil
+}
+
+// Returns the spec of the service so it can be changed in place and written back with the rest of the config.
+// If no name is provided, the config must only contain a single service.
+func GetServiceSpec(cfg *NucleusConfig, serviceName string) (*SpecStruct, error) {
+	services, err := GetServiceConfigs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if serviceName == "" && len(services) != 1 {
+		return nil, fmt.Errorf("nucleus config defines %d services, must select one with --service", len(services))
+	}
+	serviceNames := []string{}
+	if serviceName != "" {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	selected, err := SelectServiceConfigs(services, serviceNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Services) == 0 {
+		return &cfg.Spec, nil
+	}
+	for idx := range cfg.Services {
+		if cfg.Services[idx].Spec.ServiceName == selected[0].Spec.ServiceName {
+			return &cfg.Services[idx].Spec, nil
+		}
+	}
+	return nil, fmt.Errorf("service '%s' is not defined in the nucleus config", selected[0].Spec.ServiceName)
+}
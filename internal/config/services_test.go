@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServiceConfigs(t *testing.T) {
+	_, err := GetServiceConfigs(nil)
+	assert.Error(t, err)
+
+	svcs, err := GetServiceConfigs(&NucleusConfig{Spec: SpecStruct{ServiceName: "foo"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []ServiceConfig{{Spec: SpecStruct{ServiceName: "foo"}}}, svcs)
+
+	svcs, err = GetServiceConfigs(&NucleusConfig{Services: []ServiceConfig{
+		{Directory: "./foo", Spec: SpecStruct{ServiceName: "foo"}},
+		{Directory: "./bar", Spec: SpecStruct{ServiceName: "bar"}},
+	}})
+	assert.Nil(t, err)
+	assert.Len(t, svcs, 2)
+
+	_, err = GetServiceConfigs(&NucleusConfig{
+		Spec:     SpecStruct{ServiceName: "foo"},
+		Services: []ServiceConfig{{Directory: "./bar", Spec: SpecStruct{ServiceName: "bar"}}},
+	})
+	assert.Error(t, err, "should not allow spec and services together")
+
+	_, err = GetServiceConfigs(&NucleusConfig{
+		Spec:     SpecStruct{Vars: map[string]string{"FOO": "bar"}},
+		Services: []ServiceConfig{{Directory: "./bar", Spec: SpecStruct{ServiceName: "bar"}}},
+	})
+	assert.Error(t, err, "should not allow a spec without a service name alongside services")
+
+	_, err = GetServiceConfigs(&NucleusConfig{Services: []ServiceConfig{
+		{Directory: "./foo", Spec: SpecStruct{ServiceName: "foo"}},
+		{Directory: "./foo2", Spec: SpecStruct{ServiceName: "foo"}},
+	}})
+	assert.Error(t, err, "should not allow duplicate service names")
+}
+
+func TestSelectServiceConfigs(t *testing.T) {
+	foo := ServiceConfig{Directory: "./foo", Spec: SpecStruct{ServiceName: "foo"}}
+	bar := ServiceConfig{Directory: "./bar", Spec: SpecStruct{ServiceName: "bar"}}
+
+	svcs, err := SelectServiceConfigs([]ServiceConfig{foo}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []ServiceConfig{foo}, svcs)
+
+	_, err = SelectServiceConfigs([]ServiceConfig{foo, bar}, nil)
+	assert.Error(t, err, "should require a selection when there are multiple services")
+
+	svcs, err = SelectServiceConfigs([]ServiceConfig{foo, bar}, []string{"bar", " foo", "bar"})
+	assert.Nil(t, err)
+	assert.Equal(t, []ServiceConfig{bar, foo}, svcs)
+
+	_, err = SelectServiceConfigs([]ServiceConfig{foo, bar}, []string{"baz"})
+	assert.Error(t, err)
+}
+
+func TestGetServiceSpec(t *testing.T) {
+	cfg := &NucleusConfig{Spec: SpecStruct{ServiceName: "foo"}}
+	spec, err := GetServiceSpec(cfg, "")
+	assert.Nil(t, err)
+	spec.Vars = map[string]string{"FOO": "bar"}
+	assert.Equal(t, map[string]string{"FOO": "bar"}, cfg.Spec.Vars, "should return the spec of the config")
+	_, err = GetServiceSpec(cfg, "bar")
+	assert.Error(t, err)
+
+	cfg = &NucleusConfig{Services: []ServiceConfig{
+		{Directory: "./foo", Spec: SpecStruct{ServiceName: "foo"}},
+		{Directory: "./bar", Spec: SpecStruct{ServiceName: "bar"}},
+	}}
+	_, err = GetServiceSpec(cfg, "")
+	assert.Error(t, err, "should require a selection when there are multiple services")
+	spec, err = GetServiceSpec(cfg, "bar")
+	assert.Nil(t, err)
+	spec.Vars = map[string]string{"FOO": "bar"}
+	assert.Nil(t, cfg.Services[0].Spec.Vars)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, cfg.Services[1].Spec.Vars, "should return the spec of the selected service")
+	_, err = GetServiceSpec(cfg, "baz")
+	assert.Error(t, err)
+}
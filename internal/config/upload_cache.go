@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	nucleusUploadCacheName = "uploads.yaml"
+
+	// uploads older than this are not reused, since the server may have cleaned them up
+	uploadCacheTtl = 24 * time.Hour
+)
+
+// UploadCacheEntry records the last code upload of a service to an environment
+type UploadCacheEntry struct {
+	ApiEnv          string    `yaml:"apiEnv"`
+	EnvironmentName string    `yaml:"environmentName"`
+	ServiceName     string    `yaml:"serviceName"`
+	Hash            string    `yaml:"hash"`
+	UploadKey       string    `yaml:"uploadKey"`
+	UploadedAt      time.Time `yaml:"uploadedAt"`
+}
+
+type uploadCache struct {
+	Uploads []*UploadCacheEntry `yaml:"uploads"`
+}
+
+var (
+	// services are deployed concurrently, so updates to the cache file must not interleave
+	uploadCacheMu sync.Mutex
+)
+
+// Returns the upload key of the last upload of the service to the environment if its code had the same hash.
+// A cache that can't be read is treated as empty.
+func GetCachedUploadKey(apiEnv string, environmentName string, serviceName string, hash string) (string, bool) {
+	uploadCacheMu.Lock()
+	defer uploadCacheMu.Unlock()
+
+	cache, err := readUploadCache()
+	if err != nil {
+		return "", false
+	}
+	entry := cache.find(apiEnv, environmentName, serviceName)
+	if entry == nil || entry.Hash != hash || time.Since(entry.UploadedAt) > uploadCacheTtl {
+		return "", false
+	}
+	return entry.UploadKey, true
+}
+
+// Records the upload key as the last upload of the service to the environment
+func SetCachedUploadKey(apiEnv string, environmentName string, serviceName string, hash string, uploadKey string) error {
+	uploadCacheMu.Lock()
+	defer uploadCacheMu.Unlock()
+
+	cache, err := readUploadCache()
+	if err != nil {
+		cache = &uploadCache{}
+	}
+	entry := cache.find(apiEnv, environmentName, serviceName)
+	if entry == nil {
+		entry = &UploadCacheEntry{ApiEnv: apiEnv, EnvironmentName: environmentName, ServiceName: serviceName}
+		cache.Uploads = append(cache.Uploads, entry)
+	}
+	entry.Hash = hash
+	entry.UploadKey = uploadKey
+	entry.UploadedAt = time.Now().UTC()
+	return writeUploadCache(cache)
+}
+
+// Forgets the last upload of the service to the environment
+func ClearCachedUploadKey(apiEnv string, environmentName string, serviceName string) error {
+	uploadCacheMu.Lock()
+	defer uploadCacheMu.Unlock()
+
+	cache, err := readUploadCache()
+	if err != nil {
+		return nil
+	}
+	uploads := []*UploadCacheEntry{}
+	for _, entry := range cache.Uploads {
+		if entry.ApiEnv != apiEnv || entry.EnvironmentName != environmentName || entry.ServiceName != serviceName {
+			uploads = append(uploads, entry)
+		}
+	}
+	cache.Uploads = uploads
+	return writeUploadCache(cache)
+}
+
+func (c *uploadCache) find(apiEnv string, environmentName string, serviceName string) *UploadCacheEntry {
+	for _, entry := range c.Uploads {
+		if entry.ApiEnv == apiEnv && entry.EnvironmentName == environmentName && entry.ServiceName == serviceName {
+			return entry
+		}
+	}
+	return nil
+}
+
+func getUploadCachePath() (string, error) {
+	dirPath, err := GetOrCreateNucleusFolder()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirPath, nucleusUploadCacheName), nil
+}
+
+func readUploadCache() (*uploadCache, error) {
+	path, err := getUploadCachePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &uploadCache{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cache := &uploadCache{}
+	err = yaml.Unmarshal(data, cache)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func writeUploadCache(cache *uploadCache) error {
+	path, err := getUploadCachePath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
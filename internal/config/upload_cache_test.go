@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadCache(t *testing.T) {
+	t.Setenv("NUCLEUS_CONFIG_DIR", t.TempDir())
+
+	_, ok := GetCachedUploadKey("prod", "dev", "api", "sha256:abc")
+	assert.False(t, ok)
+
+	assert.Nil(t, SetCachedUploadKey("prod", "dev", "api", "sha256:abc", "key-1"))
+	assert.Nil(t, SetCachedUploadKey("prod", "dev", "web", "sha256:def", "key-2"))
+
+	key, ok := GetCachedUploadKey("prod", "dev", "api", "sha256:abc")
+	assert.True(t, ok)
+	assert.Equal(t, "key-1", key)
+
+	_, ok = GetCachedUploadKey("prod", "dev", "api", "sha256:changed")
+	assert.False(t, ok, "changed code should not reuse the upload")
+	_, ok = GetCachedUploadKey("stage", "dev", "api", "sha256:abc")
+	assert.False(t, ok, "uploads should not be shared across api environments")
+	_, ok = GetCachedUploadKey("prod", "prod", "api", "sha256:abc")
+	assert.False(t, ok, "uploads should not be shared across environments")
+
+	assert.Nil(t, SetCachedUploadKey("prod", "dev", "api", "sha256:new", "key-3"))
+	key, ok = GetCachedUploadKey("prod", "dev", "api", "sha256:new")
+	assert.True(t, ok)
+	assert.Equal(t, "key-3", key)
+
+	assert.Nil(t, ClearCachedUploadKey("prod", "dev", "api"))
+	_, ok = GetCachedUploadKey("prod", "dev", "api", "sha256:new")
+	assert.False(t, ok)
+	_, ok = GetCachedUploadKey("prod", "dev", "web", "sha256:def")
+	assert.True(t, ok)
+}
+
+func TestUploadCache_Expired(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NUCLEUS_CONFIG_DIR", dir)
+
+	assert.Nil(t, writeUploadCache(&uploadCache{Uploads: []*UploadCacheEntry{{
+		ApiEnv:          "prod",
+		EnvironmentName: "dev",
+		ServiceName:     "api",
+		Hash:            "sha256:abc",
+		UploadKey:       "key-1",
+		UploadedAt:      time.Now().Add(-2 * uploadCacheTtl),
+	}}}))
+	_, ok := GetCachedUploadKey("prod", "dev", "api", "sha256:abc")
+	assert.False(t, ok)
+
+	// a corrupt cache is treated as empty
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, nucleusUploadCacheName), []byte("uploads: ["), 0600))
+	_, ok = GetCachedUploadKey("prod", "dev", "api", "sha256:abc")
+	assert.False(t, ok)
+	assert.Nil(t, SetCachedUploadKey("prod", "dev", "api", "sha256:abc", "key-2"))
+	key, ok := GetCachedUploadKey("prod", "dev", "api", "sha256:abc")
+	assert.True(t, ok)
+	assert.Equal(t, "key-2", key)
+}
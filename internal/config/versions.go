@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	CliVersionV1Alpha1 = "nucleus-cli/v1alpha1"
+
+	// The manifest version written by this version of the CLI
+	CurrentCliVersion = CliVersionV1Alpha1
+
+	// Manifests written before the version was recorded
+	legacyCliVersion = ""
+	cliVersionKey    = "cliVersion"
+)
+
+var (
+	ErrManifestOutdated = errors.New("nucleus manifest uses an older schema version. Upgrade it via 'nucleus migrate'")
+)
+
+type manifestMigration struct {
+	from string
+	to   string
+	// Rewrites the manifest from the previous version, the version field itself is updated afterwards
+	migrate func(doc *yaml.Node) error
+}
+
+// Migrations between manifest versions, in the order they must be applied.
+// Each migration upgrades a manifest to the "from" version of the next one.
+var manifestMigrations = []manifestMigration{
+	{
+		// The first schema was identical to v1alpha1, it just didn't record its version
+		from:    legacyCliVersion,
+		to:      CliVersionV1Alpha1,
+		migrate: func(doc *yaml.Node) error { return nil },
+	},
+}
+
+// Returns every manifest version this CLI is able to read or migrate
+func GetSupportedCliVersions() []string {
+	versions := []string{}
+	for _, migration := range manifestMigrations {
+		if migration.from != legacyCliVersion {
+			versions = append(versions, migration.from)
+		}
+	}
+	return append(versions, CurrentCliVersion)
+}
+
+// Returns an error if a manifest with the given version can not be used without migrating it first
+func CheckCliVersion(version string) error {
+	if version == CurrentCliVersion {
+		return nil
+	}
+	// legacy manifests only differ by the missing version, so they can be read as is
+	if version == legacyCliVersion {
+		return nil
+	}
+	if !isKnownCliVersion(version) {
+		return fmt.Errorf(
+			"nucleus manifest version '%s' is not supported by this CLI (supported: %s). Check the cliVersion field or upgrade the CLI",
+			version,
+			strings.Join(GetSupportedCliVersions(), ", "),
+		)
+	}
+	return ErrManifestOutdated
+}
+
+func isKnownCliVersion(version string) bool {
+	if version == CurrentCliVersion {
+		return true
+	}
+	for _, migration := range manifestMigrations {
+		if migration.from == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Upgrades the raw manifest to the current version.
+// Returns the migrated manifest along with the version it was migrated from.
+func MigrateNucleusConfig(data []byte) ([]byte, string, error) {
+	doc := &yaml.Node{}
+	err := yaml.Unmarshal(data, doc)
+	if err != nil {
+		return nil, "", err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, "", fmt.Errorf("nucleus manifest must be a yaml mapping")
+	}
+	root := doc.Content[0]
+
+	fromVersion := legacyCliVersion
+	if versionNode := getYamlMappingValue(root, cliVersionKey); versionNode != nil {
+		fromVersion = versionNode.Value
+	}
+	if !isKnownCliVersion(fromVersion) {
+		return nil, "", CheckCliVersion(fromVersion)
+	}
+
+	version := fromVersion
+	for _, migration := range manifestMigrations {
+		if migration.from != version {
+			continue
+		}
+		err = migration.migrate(doc)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to migrate nucleus manifest from '%s' to '%s': %w", migration.from, migration.to, err)
+		}
+		version = migration.to
+	}
+	setCliVersion(root, version)
+
+	output, err := encodeYamlDocument(doc)
+	if err != nil {
+		return nil, "", err
+	}
+	return output, fromVersion, nil
+}
+
+func setCliVersion(root *yaml.Node, version string) {
+	if versionNode := getYamlMappingValue(root, cliVersionKey); versionNode != nil {
+		versionNode.SetString(version)
+		return
+	}
+	key := &yaml.Node{}
+	key.SetString(cliVersionKey)
+	value := &yaml.Node{}
+	value.SetString(version)
+	// the version always goes first so it is the first thing people see
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// Upgrades the nucleus manifest on disk to the current version.
+// Returns the version the manifest was migrated from.
+func MigrateNucleusConfigFile() (string, error) {
+	data, err := os.ReadFile(nucleusConfigPath)
+	if err != nil {
+		return "", err
+	}
+	output, fromVersion, err := MigrateNucleusConfig(data)
+	if err != nil {
+		return "", err
+	}
+	if fromVersion == CurrentCliVersion {
+		return fromVersion, nil
+	}
+	err = writeFileAtomic(nucleusConfigPath, output, 0644)
+	if err != nil {
+		return "", err
+	}
+	return fromVersion, nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCliVersion(t *testing.T) {
+	assert.Nil(t, CheckCliVersion(CurrentCliVersion))
+	assert.Nil(t, CheckCliVersion(""), "legacy manifests without a version should be readable")
+	assert.Error(t, CheckCliVersion("nucleus-cli/v9"))
+}
+
+func TestGetSupportedCliVersions(t *testing.T) {
+	assert.Equal(t, []string{CliVersionV1Alpha1}, GetSupportedCliVersions())
+}
+
+func TestMigrateNucleusConfig(t *testing.T) {
+	output, from, err := MigrateNucleusConfig([]byte(`# my service
+spec:
+  serviceName: foo # the name
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, "", from)
+	assert.Equal(t, `cliVersion: nucleus-cli/v1alpha1
+# my service
+spec:
+  serviceName: foo # the name
+`, string(output))
+
+	output, from, err = MigrateNucleusConfig([]byte("cliVersion: nucleus-cli/v1alpha1\nspec:\n  serviceName: foo\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, CliVersionV1Alpha1, from)
+	assert.Equal(t, "cliVersion: nucleus-cli/v1alpha1\nspec:\n  serviceName: foo\n", string(output))
+
+	_, _, err = MigrateNucleusConfig([]byte("cliVersion: nucleus-cli/v9\n"))
+	assert.Error(t, err)
+
+	_, _, err = MigrateNucleusConfig([]byte("- foo\n"))
+	assert.Error(t, err)
+}
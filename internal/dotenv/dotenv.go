@@ -0,0 +1,253 @@
+package dotenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type Format string
+
+const (
+	DotenvFormat Format = "dotenv"
+	JsonFormat   Format = "json"
+	ShellFormat  Format = "shell"
+)
+
+var (
+	formatMap = map[string]Format{
+		string(DotenvFormat): DotenvFormat,
+		string(JsonFormat):   JsonFormat,
+		string(ShellFormat):  ShellFormat,
+	}
+)
+
+func ParseFormat(str string) (Format, bool) {
+	f, ok := formatMap[strings.ToLower(str)]
+	return f, ok
+}
+
+func GetFormats() []string {
+	return []string{string(DotenvFormat), string(JsonFormat), string(ShellFormat)}
+}
+
+// Parses the contents of a .env file.
+//
+// Supports comments, an optional "export " prefix, single quoted (literal) values,
+// and double quoted values with escape sequences. Quoted values may span multiple lines.
+func Parse(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{input: strings.ReplaceAll(string(data), "\r\n", "\n"), line: 1}
+	return p.parse()
+}
+
+type parser struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (p *parser) parse() (map[string]string, error) {
+	output := map[string]string{}
+	for p.pos < len(p.input) {
+		p.skipWhitespace()
+		if p.pos >= len(p.input) {
+			break
+		}
+		switch p.input[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		line := p.line
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := output[key]; ok {
+			return nil, fmt.Errorf("line %d: key '%s' is defined more than once", line, key)
+		}
+		output[key] = value
+	}
+	return output, nil
+}
+
+func (p *parser) parseKey() (string, error) {
+	rest := p.input[p.pos:]
+	if strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		p.pos += len("export")
+		p.skipWhitespace()
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != '=' && p.input[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != '=' {
+		return "", fmt.Errorf("line %d: expected KEY=VALUE", p.line)
+	}
+	key := strings.TrimSpace(p.input[start:p.pos])
+	if key == "" {
+		return "", fmt.Errorf("line %d: missing key", p.line)
+	}
+	p.pos++ // skip =
+	return key, nil
+}
+
+func (p *parser) parseValue() (string, error) {
+	p.skipWhitespace()
+	if p.pos >= len(p.input) {
+		return "", nil
+	}
+
+	switch p.input[p.pos] {
+	case '\'':
+		return p.parseQuoted('\'')
+	case '"':
+		return p.parseQuoted('"')
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+		// an inline comment must be preceded by whitespace
+		if p.input[p.pos] == '#' && p.pos > start && isWhitespace(p.input[p.pos-1]) {
+			break
+		}
+		p.pos++
+	}
+	value := strings.TrimSpace(p.input[start:p.pos])
+	p.skipLine()
+	return value, nil
+}
+
+func (p *parser) parseQuoted(quote byte) (string, error) {
+	startLine := p.line
+	p.pos++ // skip opening quote
+
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), p.finishQuotedLine()
+		case c == '\\' && quote == '"' && p.pos+1 < len(p.input):
+			p.pos++
+			sb.WriteString(unescape(p.input[p.pos]))
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("line %d: unterminated quoted value", startLine)
+}
+
+// Only whitespace and comments may follow a quoted value
+func (p *parser) finishQuotedLine() error {
+	p.skipWhitespace()
+	if p.pos < len(p.input) && p.input[p.pos] != '\n' && p.input[p.pos] != '#' {
+		return fmt.Errorf("line %d: unexpected characters after quoted value", p.line)
+	}
+	p.skipLine()
+	return nil
+}
+
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(c)
+	default:
+		return "\\" + string(c)
+	}
+}
+
+func (p *parser) skipWhitespace() {
+	for p.pos < len(p.input) && isWhitespace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos < len(p.input) {
+		p.pos++
+		p.line++
+	}
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// Writes the vars in the given format, sorted by key
+func Write(w io.Writer, vars map[string]string, format Format) error {
+	if format == JsonFormat {
+		if vars == nil {
+			vars = map[string]string{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(vars)
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var line string
+		switch format {
+		case DotenvFormat:
+			line = fmt.Sprintf("%s=%s", key, quoteDotenv(vars[key]))
+		case ShellFormat:
+			line = fmt.Sprintf("export %s=%s", key, quoteShell(vars[key]))
+		default:
+			return fmt.Errorf("unsupported format: %s", format)
+		}
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quoteDotenv(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r#\"'\\$") {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+	return fmt.Sprintf(`"%s"`, replacer.Replace(value))
+}
+
+func quoteShell(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", `'\''`))
+}
@@ -0,0 +1,109 @@
+package dotenv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	vars, err := Parse(strings.NewReader(`# a comment
+FOO=bar
+export EXPORTED=yes
+SPACED = value with spaces   # trailing comment
+HASH=abc#123
+EMPTY=
+TOKEN=YWJjZA==
+URL=https://example.com/?a=1&b=2
+SINGLE='literal \n ${NOT_EXPANDED}'
+DOUBLE="line1\nline2 \"quoted\""
+MULTI="first
+second"
+MULTI_SINGLE='a
+b' # comment
+WINDOWS=crlf` + "\r\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"FOO":          "bar",
+		"EXPORTED":     "yes",
+		"SPACED":       "value with spaces",
+		"HASH":         "abc#123",
+		"EMPTY":        "",
+		"TOKEN":        "YWJjZA==",
+		"URL":          "https://example.com/?a=1&b=2",
+		"SINGLE":       `literal \n ${NOT_EXPANDED}`,
+		"DOUBLE":       "line1\nline2 \"quoted\"",
+		"MULTI":        "first\nsecond",
+		"MULTI_SINGLE": "a\nb",
+		"WINDOWS":      "crlf",
+	}, vars)
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse(strings.NewReader("FOO=bar\nNOT_A_PAIR\n"))
+	assert.EqualError(t, err, "line 2: expected KEY=VALUE")
+
+	_, err = Parse(strings.NewReader("FOO=\"bar\n\nBAZ=qux\n"))
+	assert.EqualError(t, err, "line 1: unterminated quoted value")
+
+	_, err = Parse(strings.NewReader("FOO='a\nb' extra\n"))
+	assert.EqualError(t, err, "line 2: unexpected characters after quoted value")
+
+	_, err = Parse(strings.NewReader("FOO=a\nFOO=b\n"))
+	assert.EqualError(t, err, "line 2: key 'FOO' is defined more than once")
+
+	_, err = Parse(strings.NewReader("=value\n"))
+	assert.EqualError(t, err, "line 1: missing key")
+}
+
+func TestWrite(t *testing.T) {
+	vars := map[string]string{
+		"B":     "plain",
+		"A":     "with space",
+		"MULTI": "a\nb",
+		"QUOTE": `it's "quoted" $HOME`,
+		"EMPTY": "",
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, Write(buf, vars, DotenvFormat))
+	assert.Equal(t, `A="with space"
+B=plain
+EMPTY=""
+MULTI="a\nb"
+QUOTE="it's \"quoted\" \$HOME"
+`, buf.String())
+
+	parsed, err := Parse(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, vars, parsed)
+
+	buf.Reset()
+	assert.Nil(t, Write(buf, vars, ShellFormat))
+	assert.Equal(t, `export A='with space'
+export B='plain'
+export EMPTY=''
+export MULTI='a
+b'
+export QUOTE='it'\''s "quoted" $HOME'
+`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, Write(buf, map[string]string{"A": "a&b"}, JsonFormat))
+	assert.Equal(t, "{\n  \"A\": \"a&b\"\n}\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, Write(buf, nil, JsonFormat))
+	assert.Equal(t, "{}\n", buf.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	format, ok := ParseFormat("JSON")
+	assert.True(t, ok)
+	assert.Equal(t, JsonFormat, format)
+
+	_, ok = ParseFormat("xml")
+	assert.False(t, ok)
+}
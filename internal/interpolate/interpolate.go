@@ -0,0 +1,143 @@
+package interpolate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	NucleusEnvBuiltIn  = "NUCLEUS_ENV"
+	ServiceNameBuiltIn = "SERVICE_NAME"
+
+	defaultSeparator = ":-"
+)
+
+type Options struct {
+	// Values that are always available for reference, like the environment being deployed to
+	BuiltIns map[string]string
+	// Used to look up references that are neither vars nor built-ins. Local env lookups are disabled when nil.
+	LookupEnv func(key string) (string, bool)
+}
+
+// Returns the built-in values for a deploy of the service to the environment
+func GetBuiltIns(environmentName string, serviceName string) map[string]string {
+	return map[string]string{
+		NucleusEnvBuiltIn:  environmentName,
+		ServiceNameBuiltIn: serviceName,
+	}
+}
+
+// Resolves every ${NAME} and ${NAME:-default} reference in the var values.
+// References are looked up in the other vars first, then the built-ins, and then the local environment (if enabled).
+// $${NAME} can be used to produce a literal ${NAME}.
+func ResolveVars(vars map[string]string, opts *Options) (map[string]string, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	r := &resolver{
+		vars:     vars,
+		opts:     opts,
+		resolved: map[string]string{},
+		visiting: map[string]bool{},
+	}
+
+	// resolve in a stable order so errors are deterministic
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	output := make(map[string]string, len(vars))
+	for _, key := range keys {
+		value, err := r.resolveVar(key, nil)
+		if err != nil {
+			return nil, err
+		}
+		output[key] = value
+	}
+	return output, nil
+}
+
+type resolver struct {
+	vars     map[string]string
+	opts     *Options
+	resolved map[string]string
+	visiting map[string]bool
+}
+
+func (r *resolver) resolveVar(key string, chain []string) (string, error) {
+	if value, ok := r.resolved[key]; ok {
+		return value, nil
+	}
+	chain = append(chain, key)
+	if r.visiting[key] {
+		return "", fmt.Errorf("vars contain a reference cycle: %s", strings.Join(chain, " -> "))
+	}
+	r.visiting[key] = true
+	defer delete(r.visiting, key)
+
+	value, err := r.expand(key, r.vars[key], chain)
+	if err != nil {
+		return "", err
+	}
+	r.resolved[key] = value
+	return value, nil
+}
+
+func (r *resolver) expand(key string, value string, chain []string) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start == -1 {
+			sb.WriteString(value)
+			return sb.String(), nil
+		}
+		// $${ escapes the reference
+		if start > 0 && value[start-1] == '$' {
+			sb.WriteString(value[:start-1])
+			sb.WriteString("${")
+			value = value[start+2:]
+			continue
+		}
+
+		end := strings.Index(value[start:], "}")
+		if end == -1 {
+			return "", fmt.Errorf("var '%s' has an unterminated reference", key)
+		}
+		end += start
+
+		sb.WriteString(value[:start])
+		reference := value[start+2 : end]
+		resolved, err := r.lookup(key, reference, chain)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(resolved)
+		value = value[end+1:]
+	}
+}
+
+func (r *resolver) lookup(key string, reference string, chain []string) (string, error) {
+	name, defaultValue, hasDefault := strings.Cut(reference, defaultSeparator)
+	if name == "" {
+		return "", fmt.Errorf("var '%s' has an empty reference", key)
+	}
+
+	if _, ok := r.vars[name]; ok {
+		return r.resolveVar(name, chain)
+	}
+	if value, ok := r.opts.BuiltIns[name]; ok {
+		return value, nil
+	}
+	if r.opts.LookupEnv != nil {
+		if value, ok := r.opts.LookupEnv(name); ok {
+			return value, nil
+		}
+	}
+	if hasDefault {
+		return defaultValue, nil
+	}
+	return "", fmt.Errorf("var '%s' references undefined variable '%s'", key, name)
+}
@@ -0,0 +1,62 @@
+package interpolate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveVars(t *testing.T) {
+	vals, err := ResolveVars(map[string]string{
+		"HOST":    "db.${NUCLEUS_ENV}.internal",
+		"DB_URL":  "postgres://${HOST}:${PORT:-5432}/${SERVICE_NAME}",
+		"LITERAL": "$${HOST} costs $5",
+		"PLAIN":   "no refs",
+	}, &Options{BuiltIns: GetBuiltIns("prod", "api")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"HOST":    "db.prod.internal",
+		"DB_URL":  "postgres://db.prod.internal:5432/api",
+		"LITERAL": "${HOST} costs $5",
+		"PLAIN":   "no refs",
+	}, vals)
+
+	vals, err = ResolveVars(nil, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, vals)
+}
+
+func TestResolveVars_Precedence(t *testing.T) {
+	lookupEnv := func(key string) (string, bool) {
+		vals := map[string]string{"USER": "local", "NUCLEUS_ENV": "local"}
+		val, ok := vals[key]
+		return val, ok
+	}
+
+	vals, err := ResolveVars(map[string]string{
+		"A":            "${USER}-${NUCLEUS_ENV}-${SERVICE_NAME}",
+		"SERVICE_NAME": "override",
+	}, &Options{BuiltIns: GetBuiltIns("prod", "api"), LookupEnv: lookupEnv})
+	assert.Nil(t, err)
+	assert.Equal(t, "local-prod-override", vals["A"], "vars should win over built-ins, which win over the local env")
+
+	_, err = ResolveVars(map[string]string{"A": "${USER}"}, &Options{})
+	assert.EqualError(t, err, "var 'A' references undefined variable 'USER'", "local env should be opt in")
+}
+
+func TestResolveVars_Errors(t *testing.T) {
+	_, err := ResolveVars(map[string]string{"A": "${B}", "B": "${C}", "C": "${A}"}, nil)
+	assert.EqualError(t, err, "vars contain a reference cycle: A -> B -> C -> A")
+
+	_, err = ResolveVars(map[string]string{"A": "${A}"}, nil)
+	assert.EqualError(t, err, "vars contain a reference cycle: A -> A")
+
+	_, err = ResolveVars(map[string]string{"A": "${MISSING}"}, nil)
+	assert.EqualError(t, err, "var 'A' references undefined variable 'MISSING'")
+
+	_, err = ResolveVars(map[string]string{"A": "${B"}, nil)
+	assert.EqualError(t, err, "var 'A' has an unterminated reference")
+
+	_, err = ResolveVars(map[string]string{"A": "${}"}, nil)
+	assert.EqualError(t, err, "var 'A' has an empty reference")
+}
@@ -1,49 +1,144 @@
 package procfile
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-
-	"gopkg.in/yaml.v2"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
+// Process is a single process type declared in a Procfile, like web or worker
+type Process struct {
+	Type    string
+	Command string
+}
+
+// Procfile holds the process types of a Heroku style Procfile, in the order they were declared
 type Procfile struct {
-	Web string `yaml:"web"`
+	Processes []*Process
+}
+
+// Warning describes a Procfile line that was skipped or overridden while parsing
+type Warning struct {
+	Line    int
+	Message string
+}
+
+func (w *Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
 }
 
 const (
-	procfilePath = "./Procfile"
+	procfileName = "Procfile"
+
+	WebProcessType = "web"
 )
 
-func DoesProcfileExist() bool {
-	_, err := os.Stat(procfilePath)
+var (
+	processLineMatcher = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
+)
+
+func getProcfilePath(dir string) string {
+	return filepath.Join(dir, procfileName)
+}
+
+func DoesProcfileExist(dir string) bool {
+	_, err := os.Stat(getProcfilePath(dir))
 	return !errors.Is(err, os.ErrNotExist)
 }
 
-func GetProcfile() (*Procfile, error) {
-	file, err := os.ReadFile(procfilePath)
+func GetProcfile(dir string) (*Procfile, []*Warning, error) {
+	file, err := os.ReadFile(getProcfilePath(dir))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return Parse(bytes.NewReader(file))
+}
+
+func SetProcfile(dir string, file *Procfile) error {
+	buf := &bytes.Buffer{}
+	for _, process := range file.Processes {
+		fmt.Fprintf(buf, "%s: %s\n", process.Type, process.Command)
 	}
 
-	data := &Procfile{}
-	err = yaml.Unmarshal(file, data)
+	err := os.WriteFile(getProcfilePath(dir), buf.Bytes(), 0644)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("unable to write data into procfile")
 	}
-	return data, nil
+	return nil
 }
 
-func SetProcfile(file *Procfile) error {
-	data, err := yaml.Marshal(&file)
-	if err != nil {
-		return err
+// Parses a Procfile of "<process type>: <command>" lines.
+// Blank lines and comments are ignored, and malformed lines are skipped and reported as warnings.
+// If a process type is declared more than once, the last declaration wins.
+func Parse(r io.Reader) (*Procfile, []*Warning, error) {
+	procfile := &Procfile{Processes: []*Process{}}
+	warnings := []*Warning{}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		matches := processLineMatcher.FindStringSubmatch(line)
+		if matches == nil {
+			warnings = append(warnings, &Warning{Line: lineNum, Message: "expected '<process type>: <command>'"})
+			continue
+		}
+		processType, command := matches[1], strings.TrimSpace(matches[2])
+		if command == "" {
+			warnings = append(warnings, &Warning{Line: lineNum, Message: fmt.Sprintf("process type '%s' has no command", processType)})
+			continue
+		}
+
+		if existing := procfile.GetProcess(processType); existing != nil {
+			warnings = append(warnings, &Warning{Line: lineNum, Message: fmt.Sprintf("process type '%s' is declared more than once, using the last declaration", processType)})
+			existing.Command = command
+			continue
+		}
+		procfile.Processes = append(procfile.Processes, &Process{Type: processType, Command: command})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
 	}
+	return procfile, warnings, nil
+}
 
-	err = os.WriteFile(procfilePath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("unable to write data into procfile")
+// Returns the process with the given type, or nil if it is not declared
+func (p *Procfile) GetProcess(processType string) *Process {
+	for _, process := range p.Processes {
+		if process.Type == processType {
+			return process
+		}
 	}
 	return nil
 }
+
+// Adds the process, or replaces the command if the process type is already declared
+func (p *Procfile) SetProcess(processType string, command string) {
+	if existing := p.GetProcess(processType); existing != nil {
+		existing.Command = command
+		return
+	}
+	p.Processes = append(p.Processes, &Process{Type: processType, Command: command})
+}
+
+// Returns every process type other than web
+func (p *Procfile) GetExtraProcessTypes() []string {
+	output := []string{}
+	for _, process := range p.Processes {
+		if process.Type != WebProcessType {
+			output = append(output, process.Type)
+		}
+	}
+	return output
+}
@@ -0,0 +1,50 @@
+package procfile
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	fixturesDir = "./fixtures"
+)
+
+func TestGetProcfile(t *testing.T) {
+	procfile, warnings, err := GetProcfile(fixturesDir)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Process{
+		{Type: "web", Command: "gunicorn app:app --bind 0.0.0.0:$PORT --log-config key: value"},
+		{Type: "worker", Command: "celery -A tasks worker --concurrency 2"},
+		{Type: "release", Command: "python manage.py migrate"},
+	}, procfile.Processes)
+	assert.Equal(t, []*Warning{
+		{Line: 6, Message: "expected '<process type>: <command>'"},
+		{Line: 7, Message: "process type 'scheduler' has no command"},
+		{Line: 8, Message: "process type 'worker' is declared more than once, using the last declaration"},
+	}, warnings)
+	assert.Equal(t, []string{"worker", "release"}, procfile.GetExtraProcessTypes())
+}
+
+func TestSetProcfile(t *testing.T) {
+	dir := t.TempDir()
+	assert.False(t, DoesProcfileExist(dir))
+
+	procfile := &Procfile{}
+	procfile.SetProcess(WebProcessType, "uvicorn main:app --host 0.0.0.0 --port $PORT")
+	procfile.SetProcess("worker", "python worker.py")
+	procfile.SetProcess("worker", "python worker.py --verbose")
+	assert.Nil(t, SetProcfile(dir, procfile))
+	assert.True(t, DoesProcfileExist(dir))
+
+	data, err := os.ReadFile(getProcfilePath(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, "web: uvicorn main:app --host 0.0.0.0 --port $PORT\nworker: python worker.py --verbose\n", string(data))
+
+	parsed, warnings, err := Parse(strings.NewReader(string(data)))
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, procfile, parsed)
+}
@@ -16,6 +16,8 @@ const (
 	autoProgress  ProgressType = "auto"
 	PlainProgress ProgressType = "plain"
 	TtyProgress   ProgressType = "tty"
+	// one json event per line on stdout, for tooling that wraps the CLI
+	JsonProgress ProgressType = "json"
 )
 
 var (
@@ -23,6 +25,7 @@ var (
 		string(autoProgress):  autoProgress,
 		string(PlainProgress): PlainProgress,
 		string(TtyProgress):   TtyProgress,
+		string(JsonProgress):  JsonProgress,
 	}
 )
 
@@ -70,7 +73,7 @@ func parseProgressString(str string) (ProgressType, bool) {
 }
 
 func SProgressPrint(progressType ProgressType, colorAttr color.Attribute) func(a ...interface{}) string {
-	if progressType == PlainProgress {
+	if progressType != TtyProgress {
 		return fmt.Sprint
 	}
 	return nterm.GetColoredSprintFunc(colorAttr)
@@ -4,27 +4,82 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
+// ProjectToml is a Cloud Native Buildpacks project descriptor.
+// Schema version 0.2 uses the [_] and [io.buildpacks] tables, while 0.1 uses [project] and [build].
 type ProjectToml struct {
-	Io    Io
-	Build Build
+	Project       Project                `toml:"_"`
+	LegacyProject Project                `toml:"project"`
+	Io            Io                     `toml:"io"`
+	Build         Build                  `toml:"build"`
+	Metadata      map[string]interface{} `toml:"metadata"`
+}
+
+type Project struct {
+	SchemaVersion    string                 `toml:"schema-version"`
+	Id               string                 `toml:"id"`
+	Name             string                 `toml:"name"`
+	Version          string                 `toml:"version"`
+	Authors          []string               `toml:"authors"`
+	DocumentationUrl string                 `toml:"documentation-url"`
+	SourceUrl        string                 `toml:"source-url"`
+	Licenses         []License              `toml:"licenses"`
+	Metadata         map[string]interface{} `toml:"metadata"`
+}
+
+type License struct {
+	Type string `toml:"type"`
+	Uri  string `toml:"uri"`
 }
 
 type Io struct {
-	Buildpacks Buildpacks
+	Buildpacks Buildpacks `toml:"buildpacks"`
 }
 type Buildpacks struct {
-	Build Build
+	Builder string         `toml:"builder"`
+	Include []string       `toml:"include"`
+	Exclude []string       `toml:"exclude"`
+	Group   []BuildpackRef `toml:"group"`
+	Build   Build          `toml:"build"`
 }
 type Build struct {
-	Env []map[string]string
+	Include    []string            `toml:"include"`
+	Exclude    []string            `toml:"exclude"`
+	Buildpacks []BuildpackRef      `toml:"buildpacks"`
+	Env        []map[string]string `toml:"env"`
+}
+
+// BuildpackRef selects a buildpack by id, by uri, or both
+type BuildpackRef struct {
+	Id      string `toml:"id"`
+	Version string `toml:"version"`
+	Uri     string `toml:"uri"`
+}
+
+func (b *BuildpackRef) String() string {
+	name := b.Id
+	if name == "" {
+		name = b.Uri
+	}
+	if b.Version != "" {
+		return fmt.Sprintf("%s@%s", name, b.Version)
+	}
+	return name
 }
 
 const (
 	ProjectTomlPath = "./project.toml"
+
+	SchemaVersionV01 = "0.1"
+	SchemaVersionV02 = "0.2"
+)
+
+var (
+	supportedSchemaVersions = []string{SchemaVersionV01, SchemaVersionV02}
 )
 
 func DoesProjectFileExist(filePath string) bool {
@@ -65,6 +120,7 @@ func GetBuildEnvVars(project *ProjectToml) (map[string]string, error) {
 	return buildEvs, nil
 }
 
+// Reads and validates the project descriptor at the path
 func GetProjectFile(filePath string) (*ProjectToml, error) {
 	file, err := os.ReadFile(filePath)
 	if err != nil {
@@ -76,5 +132,73 @@ func GetProjectFile(filePath string) (*ProjectToml, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = ValidateProjectFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
 	return data, nil
 }
+
+// Returns the schema version of the descriptor. Descriptors without a [_] schema-version are 0.1.
+func GetSchemaVersion(project *ProjectToml) string {
+	if project.Project.SchemaVersion != "" {
+		return project.Project.SchemaVersion
+	}
+	return SchemaVersionV01
+}
+
+func ValidateProjectFile(project *ProjectToml) error {
+	version := GetSchemaVersion(project)
+	supported := false
+	for _, current := range supportedSchemaVersions {
+		if version == current {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported schema-version '%s', must be one of: %s", version, strings.Join(supportedSchemaVersions, ", "))
+	}
+
+	include, exclude := GetIncludeExclude(project)
+	if len(include) > 0 && len(exclude) > 0 {
+		return fmt.Errorf("project descriptor cannot have both include and exclude defined")
+	}
+
+	for idx, bp := range GetBuildpackGroup(project) {
+		if bp.Id == "" && bp.Uri == "" {
+			return fmt.Errorf("buildpack %d in the group must provide an id or uri", idx+1)
+		}
+	}
+	return nil
+}
+
+// Returns the project metadata for the schema version of the descriptor
+func GetMetadata(project *ProjectToml) *Project {
+	if GetSchemaVersion(project) == SchemaVersionV01 {
+		return &project.LegacyProject
+	}
+	return &project.Project
+}
+
+// Returns the include and exclude globs that select which files of the source directory are sent to the build
+func GetIncludeExclude(project *ProjectToml) ([]string, []string) {
+	if project == nil {
+		return nil, nil
+	}
+	if GetSchemaVersion(project) == SchemaVersionV01 {
+		return project.Build.Include, project.Build.Exclude
+	}
+	return project.Io.Buildpacks.Include, project.Io.Buildpacks.Exclude
+}
+
+// Returns the buildpacks that the descriptor selects, in the order they should run
+func GetBuildpackGroup(project *ProjectToml) []BuildpackRef {
+	if project == nil {
+		return nil
+	}
+	if GetSchemaVersion(project) == SchemaVersionV01 {
+		return project.Build.Buildpacks
+	}
+	return project.Io.Buildpacks.Group
+}
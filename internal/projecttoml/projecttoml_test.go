@@ -8,6 +8,8 @@ import (
 
 const (
 	project1FilePath = "./fixtures/project1.toml"
+	project2FilePath = "./fixtures/project2.toml"
+	legacyFilePath   = "./fixtures/legacy.toml"
 	invalidFilePath  = "./fixtures/idontexist.toml"
 )
 
@@ -35,6 +37,70 @@ func TestGetProjectFile(t *testing.T) {
 	})
 }
 
+func TestGetProjectFile_V02(t *testing.T) {
+	project, err := GetProjectFile(project2FilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, SchemaVersionV02, GetSchemaVersion(project))
+	assert.Equal(t, &Project{
+		SchemaVersion: "0.2",
+		Id:            "io.nucleus.example",
+		Name:          "Example App",
+		Version:       "1.0.0",
+		Authors:       []string{"Nucleus"},
+		SourceUrl:     "https://github.com/nucleuscloud/example",
+		Licenses:      []License{{Type: "MIT"}},
+		Metadata:      map[string]interface{}{"team": "platform"},
+	}, GetMetadata(project))
+	assert.Equal(t, "paketobuildpacks/builder:base", project.Io.Buildpacks.Builder)
+
+	include, exclude := GetIncludeExclude(project)
+	assert.Empty(t, include)
+	assert.Equal(t, []string{"*.md", "tests/"}, exclude)
+
+	group := GetBuildpackGroup(project)
+	assert.Equal(t, []BuildpackRef{
+		{Id: "paketo-buildpacks/go", Version: "1.0.0"},
+		{Uri: "docker://example.com/buildpacks/custom"},
+	}, group)
+	assert.Equal(t, "paketo-buildpacks/go@1.0.0", group[0].String())
+	assert.Equal(t, "docker://example.com/buildpacks/custom", group[1].String())
+
+	vals, err := GetBuildEnvVars(project)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"BP_GO_TARGETS": "./cmd/web"}, vals)
+}
+
+func TestGetProjectFile_V01(t *testing.T) {
+	project, err := GetProjectFile(legacyFilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, SchemaVersionV01, GetSchemaVersion(project))
+	assert.Equal(t, "io.nucleus.legacy", GetMetadata(project).Id)
+
+	include, exclude := GetIncludeExclude(project)
+	assert.Equal(t, []string{"cmd/", "go.mod", "go.sum"}, include)
+	assert.Empty(t, exclude)
+	assert.Equal(t, []BuildpackRef{{Id: "paketo-buildpacks/go"}}, GetBuildpackGroup(project))
+}
+
+func TestValidateProjectFile(t *testing.T) {
+	assert.Nil(t, ValidateProjectFile(&ProjectToml{}))
+
+	err := ValidateProjectFile(&ProjectToml{Project: Project{SchemaVersion: "0.3"}})
+	assert.EqualError(t, err, "unsupported schema-version '0.3', must be one of: 0.1, 0.2")
+
+	err = ValidateProjectFile(&ProjectToml{
+		Project: Project{SchemaVersion: SchemaVersionV02},
+		Io:      Io{Buildpacks: Buildpacks{Include: []string{"src"}, Exclude: []string{"tests"}}},
+	})
+	assert.EqualError(t, err, "project descriptor cannot have both include and exclude defined")
+
+	err = ValidateProjectFile(&ProjectToml{
+		Project: Project{SchemaVersion: SchemaVersionV02},
+		Io:      Io{Buildpacks: Buildpacks{Group: []BuildpackRef{{Version: "1.0.0"}}}},
+	})
+	assert.EqualError(t, err, "buildpack 1 in the group must provide an id or uri")
+}
+
 func TestGetBuildEnvsFromFile(t *testing.T) {
 	file, err := GetProjectFile(project1FilePath)
 	assert.Nil(t, err)
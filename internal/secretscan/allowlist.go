@@ -0,0 +1,89 @@
+package secretscan
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+const (
+	AllowlistFileName = ".nucleussecretsignore"
+)
+
+// Allowlist holds findings that were reviewed and are safe to upload.
+//
+// Each line of the allowlist file is a gitignore style path pattern, optionally followed by a rule id.
+// Without a rule id every finding in the matching files is allowed.
+type Allowlist struct {
+	entries []*allowlistEntry
+}
+
+type allowlistEntry struct {
+	matcher *gitignore.GitIgnore
+	rule    string
+}
+
+// Loads the allowlist file from the directory. An empty allowlist is returned if the file does not exist.
+func LoadAllowlist(dir string) (*Allowlist, error) {
+	fd, err := os.Open(filepath.Join(dir, AllowlistFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Allowlist{}, nil
+		}
+		return nil, err
+	}
+	defer fd.Close()
+
+	allowlist, err := ParseAllowlist(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AllowlistFileName, err)
+	}
+	return allowlist, nil
+}
+
+func ParseAllowlist(r io.Reader) (*Allowlist, error) {
+	allowlist := &Allowlist{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected '<path pattern> [rule id]'", lineNum)
+		}
+		entry := &allowlistEntry{matcher: gitignore.CompileIgnoreLines(fields[0])}
+		if len(fields) == 2 {
+			entry.rule = fields[1]
+		}
+		allowlist.entries = append(allowlist.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return allowlist, nil
+}
+
+func (a *Allowlist) IsAllowed(finding *Finding) bool {
+	if a == nil {
+		return false
+	}
+	for _, entry := range a.entries {
+		if entry.rule != "" && entry.rule != finding.Rule {
+			continue
+		}
+		if entry.matcher.MatchesPath(finding.Path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package secretscan
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Finding is a file, or a line in a file, that looks like it contains a secret
+type Finding struct {
+	// slash separated path of the file in the archive
+	Path string
+	// 1-based line of the match, 0 when the whole file is flagged
+	Line   int
+	Rule   string
+	Reason string
+}
+
+func (f *Finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s (%s)", f.Path, f.Reason, f.Rule)
+	}
+	return fmt.Sprintf("%s:%d: %s (%s)", f.Path, f.Line, f.Reason, f.Rule)
+}
+
+const (
+	SensitiveFileRule = "sensitive-file"
+	AwsAccessKeyRule  = "aws-access-key-id"
+	AwsSecretKeyRule  = "aws-secret-access-key"
+	PrivateKeyRule    = "private-key"
+	GithubTokenRule   = "github-token"
+	HighEntropyRule   = "high-entropy-secret"
+
+	// larger files are only checked by name
+	maxScanSize = 1024 * 1024
+	// same heuristic as git, a NUL byte in the first 8000 bytes means the file is binary
+	binarySniffSize = 8000
+
+	minEntropyLength = 20
+	minEntropy       = 4.0
+)
+
+type contentRule struct {
+	id      string
+	reason  string
+	pattern *regexp.Regexp
+	// when set, the first capture group must look random for the line to be flagged
+	checkEntropy bool
+}
+
+var (
+	contentRules = []*contentRule{
+		{
+			id:      AwsAccessKeyRule,
+			reason:  "AWS access key id",
+			pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AIDA|AROA|AGPA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+		},
+		{
+			id:      AwsSecretKeyRule,
+			reason:  "AWS secret access key",
+			pattern: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key\s*[:=]\s*['"]?[A-Za-z0-9/+]{40}\b`),
+		},
+		{
+			id:      PrivateKeyRule,
+			reason:  "private key",
+			pattern: regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY(?: BLOCK)?-----`),
+		},
+		{
+			id:      GithubTokenRule,
+			reason:  "GitHub token",
+			pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`),
+		},
+		{
+			id:           HighEntropyRule,
+			reason:       "high entropy value assigned to a secret-like key",
+			pattern:      regexp.MustCompile(`(?i)[a-z0-9_.-]*(?:secret|token|passw(?:or)?d|api_?key|access_?key|private_?key|credentials?)[a-z0-9_.-]*['"]?\s*[:=]\s*['"]?([A-Za-z0-9/+_=.-]+)`),
+			checkEntropy: true,
+		},
+	}
+
+	// safe to ship examples of env files
+	envFileExampleSuffixes = []string{".example", ".sample", ".template", ".dist", ".defaults"}
+
+	sensitiveFileNames = map[string]string{
+		"id_rsa":           "SSH private key",
+		"id_dsa":           "SSH private key",
+		"id_ecdsa":         "SSH private key",
+		"id_ed25519":       "SSH private key",
+		".netrc":           "netrc credentials",
+		".pgpass":          "postgres password file",
+		".htpasswd":        "htpasswd file",
+		".git-credentials": "git credentials",
+		".dockercfg":       "docker registry credentials",
+	}
+
+	sensitiveFileExtensions = map[string]string{
+		".key":      "private key file",
+		".p12":      "PKCS#12 keystore",
+		".pfx":      "PKCS#12 keystore",
+		".jks":      "java keystore",
+		".keystore": "keystore",
+	}
+
+	sensitiveFilePaths = map[string]string{
+		".aws/credentials":    "AWS credentials",
+		".docker/config.json": "docker registry credentials",
+	}
+)
+
+// Scans every file in a gzipped tarball, like the ones written by archive.GzipDirectory.
+// Findings that match the allowlist are left out. The allowlist may be nil.
+func ScanArchive(r io.Reader, allowlist *Allowlist) ([]*Finding, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	findings := []*Finding{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		fileFindings := []*Finding{}
+		if reason, ok := getSensitiveFileReason(header.Name); ok {
+			fileFindings = append(fileFindings, &Finding{Path: header.Name, Rule: SensitiveFileRule, Reason: reason})
+		}
+		if header.Size <= maxScanSize {
+			contentFindings, err := ScanFile(header.Name, tr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", header.Name, err)
+			}
+			fileFindings = append(fileFindings, contentFindings...)
+		}
+
+		for _, finding := range fileFindings {
+			if !allowlist.IsAllowed(finding) {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// Scans the contents of a single file line by line. Binary files are skipped.
+func ScanFile(name string, r io.Reader) ([]*Finding, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxScanSize+1))
+	if err != nil {
+		return nil, err
+	}
+	findings := []*Finding{}
+	if len(data) > maxScanSize || isBinary(data) {
+		return findings, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScanSize+1)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		// rules go from most to least specific, and a line is only reported once
+		for _, rule := range contentRules {
+			if rule.matches(line) {
+				findings = append(findings, &Finding{Path: name, Line: lineNum, Rule: rule.id, Reason: rule.reason})
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+func (r *contentRule) matches(line string) bool {
+	if !r.checkEntropy {
+		return r.pattern.MatchString(line)
+	}
+	for _, match := range r.pattern.FindAllStringSubmatch(line, -1) {
+		value := match[1]
+		if len(value) >= minEntropyLength && getEntropy(value) >= minEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns why a file is dangerous to ship based on its name alone
+func getSensitiveFileReason(name string) (string, bool) {
+	base := path.Base(name)
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		for _, suffix := range envFileExampleSuffixes {
+			if strings.HasSuffix(base, suffix) {
+				return "", false
+			}
+		}
+		return "env file", true
+	}
+	if reason, ok := sensitiveFileNames[base]; ok {
+		return reason, true
+	}
+	if reason, ok := sensitiveFileExtensions[path.Ext(base)]; ok {
+		return reason, true
+	}
+	for suffix, reason := range sensitiveFilePaths {
+		if name == suffix || strings.HasSuffix(name, "/"+suffix) {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffSize {
+		data = data[:binarySniffSize]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
+
+// Shannon entropy of the string in bits per character
+func getEntropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, c := range value {
+		counts[c]++
+	}
+	entropy := 0.0
+	length := float64(len(value))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,92 @@
+package upload
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// only this much of an error response body is read and reported
+	maxErrorBodySize = 64 * 1024
+	maxRawBodyLength = 512
+)
+
+// ResponseError is returned when the storage service rejects an upload.
+// Code, Message and RequestId are filled in when the body is an S3 style XML error.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+	RequestId  string
+	// the raw body, only set when it could not be parsed
+	Body string
+}
+
+type xmlError struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestId string   `xml:"RequestId"`
+}
+
+func newResponseError(rsp *http.Response) *ResponseError {
+	rspErr := &ResponseError{
+		StatusCode: rsp.StatusCode,
+		Status:     rsp.Status,
+	}
+	body, _ := io.ReadAll(io.LimitReader(rsp.Body, maxErrorBodySize))
+
+	parsed := &xmlError{}
+	if err := xml.Unmarshal(body, parsed); err == nil {
+		rspErr.Code = parsed.Code
+		rspErr.Message = parsed.Message
+		rspErr.RequestId = parsed.RequestId
+		return rspErr
+	}
+
+	rspErr.Body = strings.TrimSpace(string(body))
+	if len(rspErr.Body) > maxRawBodyLength {
+		rspErr.Body = rspErr.Body[:maxRawBodyLength] + "..."
+	}
+	return rspErr
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("upload failed with status %s", e.Status)
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Code)
+	}
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.RequestId != "" {
+		msg = fmt.Sprintf("%s (request id: %s)", msg, e.RequestId)
+	}
+	if e.Body != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Body)
+	}
+	return msg
+}
+
+// Reports whether the signed url is no longer valid, in which case a new one is needed before retrying
+func (e *ResponseError) IsExpired() bool {
+	if e.Code == "ExpiredToken" {
+		return true
+	}
+	return e.StatusCode == http.StatusForbidden && strings.Contains(strings.ToLower(e.Message), "expired")
+}
+
+// Reports whether the same request may succeed if it is sent again
+func (e *ResponseError) IsTransient() bool {
+	switch e.Code {
+	case "RequestTimeout", "SlowDown", "InternalError", "ServiceUnavailable":
+		return true
+	}
+	return e.StatusCode >= http.StatusInternalServerError ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout
+}
@@ -0,0 +1,110 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// SignedUrl is a location the file can be PUT to, along with the key that identifies the upload afterwards
+type SignedUrl struct {
+	Url       string
+	UploadKey string
+}
+
+// SignedUrlFunc returns a new signed url. It is called once up front and again whenever the current url expires.
+type SignedUrlFunc func(ctx context.Context) (*SignedUrl, error)
+
+type RetryOptions struct {
+	// total number of attempts, including the first one
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// called before waiting to retry a failed attempt
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+func DefaultRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+	}
+}
+
+// Uploads the file to a signed url and returns the upload key of the url that succeeded.
+// Network errors and transient responses are retried with exponential backoff and jitter,
+// and an expired signed url is replaced with a new one before retrying.
+// Every attempt sends the whole file again, since a signed PUT can't be resumed part way through.
+func UploadFileWithRetry(
+	ctx context.Context,
+	getSignedUrl SignedUrlFunc,
+	fd *os.File,
+	onProgress ProgressFunc,
+	opts *RetryOptions,
+) (string, error) {
+	if opts == nil {
+		opts = DefaultRetryOptions()
+	}
+
+	signedUrl, err := getSignedUrl(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = UploadFile(ctx, signedUrl.Url, fd, onProgress)
+		if err == nil {
+			return signedUrl.UploadKey, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		var rspErr *ResponseError
+		isResponseErr := errors.As(err, &rspErr)
+		isExpired := isResponseErr && rspErr.IsExpired()
+		if isResponseErr && !isExpired && !rspErr.IsTransient() {
+			return "", err
+		}
+		if attempt >= opts.MaxAttempts {
+			return "", fmt.Errorf("upload failed after %d attempts: %w", attempt, err)
+		}
+
+		wait := getBackoff(opts, attempt)
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+
+		if isExpired {
+			signedUrl, err = getSignedUrl(ctx)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// Returns the exponential backoff for the attempt, with jitter of up to half of it so concurrent uploads spread out
+func getBackoff(opts *RetryOptions, attempt int) time.Duration {
+	backoff := opts.InitialBackoff
+	for i := 1; i < attempt && backoff < opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > opts.MaxBackoff {
+		backoff = opts.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,134 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	expiredResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Request has expired</Message><RequestId>ABC123</RequestId></Error>`
+	slowDownResponse   = `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`
+	badRequestResponse = `<Error><Code>InvalidArgument</Code><Message>Bad header</Message><RequestId>XYZ</RequestId></Error>`
+)
+
+func TestUploadFileWithRetry_Transient(t *testing.T) {
+	fd := writeTempFile(t, []byte("data"))
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "data", string(body))
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, slowDownResponse)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	retries := 0
+	opts := getTestRetryOptions()
+	opts.OnRetry = func(attempt int, err error, wait time.Duration) {
+		retries++
+		assert.EqualError(t, err, "upload failed with status 503 Service Unavailable: SlowDown: Please reduce your request rate.")
+	}
+	restarts := 0
+	var lastSent int64
+	onProgress := func(sent int64, total int64) {
+		if sent == 0 {
+			restarts++
+		}
+		lastSent = sent
+	}
+	key, err := UploadFileWithRetry(context.Background(), getTestSignedUrls(server.URL), fd, onProgress, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "key-1", key)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, retries)
+	assert.Equal(t, 3, restarts, "progress should start over with every attempt")
+	assert.Equal(t, int64(4), lastSent)
+}
+
+func TestUploadFileWithRetry_Expired(t *testing.T) {
+	fd := writeTempFile(t, []byte("data"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/1" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, expiredResponse)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	key, err := UploadFileWithRetry(context.Background(), getTestSignedUrls(server.URL), fd, nil, getTestRetryOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, "key-2", key, "should use the key of the refreshed url")
+}
+
+func TestUploadFileWithRetry_Errors(t *testing.T) {
+	fd := writeTempFile(t, []byte("data"))
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path == "/1" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, badRequestResponse)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "something broke")
+	}))
+	defer server.Close()
+
+	_, err := UploadFileWithRetry(context.Background(), getTestSignedUrls(server.URL), fd, nil, getTestRetryOptions())
+	assert.EqualError(t, err, "upload failed with status 400 Bad Request: InvalidArgument: Bad header (request id: XYZ)")
+	assert.Equal(t, 1, attempts, "client errors should not be retried")
+
+	attempts = 0
+	getUrl := func(ctx context.Context) (*SignedUrl, error) {
+		return &SignedUrl{Url: server.URL + "/other", UploadKey: "key"}, nil
+	}
+	_, err = UploadFileWithRetry(context.Background(), getUrl, fd, nil, getTestRetryOptions())
+	assert.EqualError(t, err, "upload failed after 3 attempts: upload failed with status 500 Internal Server Error: something broke")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestGetBackoff(t *testing.T) {
+	opts := &RetryOptions{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for i := 0; i < 20; i++ {
+		backoff := getBackoff(opts, 1)
+		assert.GreaterOrEqual(t, backoff, 500*time.Millisecond)
+		assert.LessOrEqual(t, backoff, time.Second)
+
+		backoff = getBackoff(opts, 3)
+		assert.GreaterOrEqual(t, backoff, 2*time.Second)
+		assert.LessOrEqual(t, backoff, 4*time.Second)
+
+		backoff = getBackoff(opts, 10)
+		assert.GreaterOrEqual(t, backoff, 2500*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 5*time.Second)
+	}
+}
+
+func getTestRetryOptions() *RetryOptions {
+	return &RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+}
+
+// Returns signed urls that point at /1, /2, ... with matching upload keys
+func getTestSignedUrls(baseUrl string) SignedUrlFunc {
+	calls := 0
+	return func(ctx context.Context) (*SignedUrl, error) {
+		calls++
+		return &SignedUrl{Url: fmt.Sprintf("%s/%d", baseUrl, calls), UploadKey: fmt.Sprintf("key-%d", calls)}, nil
+	}
+}
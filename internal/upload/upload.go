@@ -0,0 +1,107 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	dialTimeout           = 30 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = time.Minute
+)
+
+var (
+	// the body can take any amount of time to send, so only connecting and waiting for the response are bounded
+	uploadClient = newUploadClient(dialTimeout, tlsHandshakeTimeout, responseHeaderTimeout)
+)
+
+// ProgressFunc is called as the upload body is sent, with the number of bytes sent so far and the total size.
+// Every time the body is sent from the start, including for a retry, it is first called with sent set to 0.
+type ProgressFunc func(sent int64, total int64)
+
+// Streams the file to the signed URL with a PUT request.
+// The body is read straight from the file, so the archive is never held in memory.
+// There is no overall timeout, slow uploads run until they finish or the context is cancelled.
+// A server that stops answering fails the upload instead, once the body has been sent and no response arrives.
+func UploadFile(ctx context.Context, signedURL string, fd *os.File, onProgress ProgressFunc) error {
+	info, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	getBody := func() (io.ReadCloser, error) {
+		// a new body means the file is sent again, so any earlier progress is void
+		if onProgress != nil {
+			onProgress(0, size)
+		}
+		return &progressReader{
+			reader:     io.NewSectionReader(fd, 0, size),
+			total:      size,
+			onProgress: onProgress,
+		}, nil
+	}
+	body, _ := getBody()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, signedURL, body)
+	if err != nil {
+		return err
+	}
+	// S3 rejects chunked uploads to signed URLs, so the length must be known up front
+	req.ContentLength = size
+	req.GetBody = getBody
+	req.Header.Set("content-type", "application/gzip")
+
+	rsp, err := uploadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return newResponseError(rsp)
+	}
+	return nil
+}
+
+func newUploadClient(dial time.Duration, tlsHandshake time.Duration, responseHeader time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   dial,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   tlsHandshake,
+			ResponseHeaderTimeout: responseHeader,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+type progressReader struct {
+	reader     io.Reader
+	sent       int64
+	total      int64
+	onProgress ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 && r.onProgress != nil {
+		r.sent += int64(n)
+		r.onProgress(r.sent, r.total)
+	}
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadFile(t *testing.T) {
+	contents := bytes.Repeat([]byte("nucleus"), 100000)
+	fd := writeTempFile(t, contents)
+
+	var received []byte
+	var contentLength int64
+	var transferEncoding []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "application/gzip", r.Header.Get("content-type"))
+		contentLength = r.ContentLength
+		transferEncoding = r.TransferEncoding
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var lastSent, lastTotal int64
+	calls := 0
+	err := UploadFile(context.Background(), server.URL, fd, func(sent int64, total int64) {
+		assert.GreaterOrEqual(t, sent, lastSent)
+		lastSent, lastTotal = sent, total
+		calls++
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, contents, received)
+	assert.Equal(t, int64(len(contents)), contentLength)
+	assert.Empty(t, transferEncoding, "upload must not be chunked")
+	assert.Equal(t, int64(len(contents)), lastSent)
+	assert.Equal(t, int64(len(contents)), lastTotal)
+	assert.Greater(t, calls, 1)
+}
+
+func TestUploadFile_Error(t *testing.T) {
+	fd := writeTempFile(t, []byte("data"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := UploadFile(context.Background(), server.URL, fd, nil)
+	assert.EqualError(t, err, "upload failed with status 403 Forbidden")
+}
+
+func TestUploadFile_ResponseTimeout(t *testing.T) {
+	fd := writeTempFile(t, []byte("data"))
+	client := uploadClient
+	uploadClient = newUploadClient(time.Second, time.Second, 50*time.Millisecond)
+	t.Cleanup(func() { uploadClient = client })
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	err := UploadFile(context.Background(), server.URL, fd, nil)
+	assert.ErrorContains(t, err, "timeout awaiting response headers")
+}
+
+func writeTempFile(t *testing.T, contents []byte) *os.File {
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	assert.Nil(t, os.WriteFile(path, contents, 0644))
+	fd, err := os.Open(path)
+	assert.Nil(t, err)
+	t.Cleanup(func() { fd.Close() })
+	return fd
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -10,9 +11,20 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+const (
+	ValidNamePattern   = "^[a-z][a-z1-9-]*$"
+	ValidVarKeyPattern = "^[A-Za-z_][A-Za-z0-9_]*$"
+)
+
 var (
 	ErrInvalidServiceName = fmt.Errorf("invalid name")
-	validNameMatcher      = regexp.MustCompile("^[a-z][a-z1-9-]*$").MatchString
+	validNameMatcher      = regexp.MustCompile(ValidNamePattern).MatchString
+	validVarKeyMatcher    = regexp.MustCompile(ValidVarKeyPattern).MatchString
+
+	// Vars that are set by nucleus at runtime and can't be overridden
+	reservedVarKeys = []string{
+		"PORT",
+	}
 )
 
 // Auth Vars
@@ -28,6 +40,19 @@ func IsValidName(s string) bool {
 	return validNameMatcher(s)
 }
 
+// Returns an error if the key can't be used as an environment variable name
+func ValidateVarKey(key string) error {
+	if !validVarKeyMatcher(key) {
+		return fmt.Errorf("invalid var key '%s': must start with a letter or underscore and only contain letters, digits and underscores", key)
+	}
+	for _, reserved := range reservedVarKeys {
+		if strings.EqualFold(key, reserved) {
+			return fmt.Errorf("var key '%s' is reserved by nucleus", key)
+		}
+	}
+	return nil
+}
+
 func GetGrpcTrailer() grpc.CallOption {
 	// see https://github.com/grpc/grpc-go/blob/master/Documentation/grpc-metadata.md
 	var trailer metadata.MD
@@ -33,3 +33,15 @@ func TestValidateRuntime(t *testing.T) {
 	assert.True(t, IsValidRuntime("java"))
 	assert.True(t, IsValidRuntime("dotnet"))
 }
+
+func TestValidateVarKey(t *testing.T) {
+	assert.NoError(t, ValidateVarKey("DATABASE_URL"))
+	assert.NoError(t, ValidateVarKey("_private"))
+	assert.NoError(t, ValidateVarKey("key2"))
+	assert.Error(t, ValidateVarKey(""))
+	assert.Error(t, ValidateVarKey("2KEY"))
+	assert.Error(t, ValidateVarKey("MY-KEY"))
+	assert.Error(t, ValidateVarKey("MY KEY"))
+	assert.Error(t, ValidateVarKey("PORT"))
+	assert.Error(t, ValidateVarKey("port"))
+}
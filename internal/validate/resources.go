@@ -0,0 +1,61 @@
+package validate
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/nucleuscloud/cli/internal/config"
+)
+
+func ValidateResources(reqs config.ResourceRequirements) error {
+	if reqs.Minimum.Cpu != "" {
+		_, err := mustParseResource(reqs.Minimum.Cpu)
+		if err != nil {
+			return fmt.Errorf("minimum cpu is not valid: %s", err.Error())
+		}
+	}
+	if reqs.Minimum.Memory != "" {
+		_, err := mustParseResource(reqs.Minimum.Memory)
+		if err != nil {
+			return fmt.Errorf("minimum memory is not valid: %s", err.Error())
+		}
+	}
+	if reqs.Maximum.Cpu != "" {
+		_, err := mustParseResource(reqs.Maximum.Cpu)
+		if err != nil {
+			return fmt.Errorf("maximum cpu is not valid: %s", err.Error())
+		}
+	}
+	if reqs.Maximum.Memory != "" {
+		_, err := mustParseResource(reqs.Maximum.Memory)
+		if err != nil {
+			return fmt.Errorf("maximum memory is not valid: %s", err.Error())
+		}
+	}
+
+	if reqs.Minimum.Cpu != "" && reqs.Maximum.Cpu != "" {
+		minCpu, _ := mustParseResource(reqs.Minimum.Cpu)
+		maxCpu, _ := mustParseResource(reqs.Maximum.Cpu)
+
+		if minCpu.Cmp(*maxCpu) == 1 {
+			return fmt.Errorf("min cpu must be less than max cpu")
+		}
+	}
+	if reqs.Minimum.Memory != "" && reqs.Maximum.Memory != "" {
+		minMem, _ := mustParseResource(reqs.Minimum.Memory)
+		maxMem, _ := mustParseResource(reqs.Maximum.Memory)
+		if minMem.Cmp(*maxMem) == 1 {
+			return fmt.Errorf("min memory must be less than max memory")
+		}
+	}
+	return nil
+}
+
+func mustParseResource(val string) (*resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(val)
+	if err != nil {
+		return nil, err
+	}
+	return &quantity, nil
+}
@@ -0,0 +1,114 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+const (
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+	jsonSchemaId    = "https://github.com/nucleuscloud/cli/blob/main/schema/nucleus.schema.json"
+)
+
+type jsonSchema = map[string]interface{}
+
+var (
+	// Fields that are required for the struct to be valid, keyed by struct name
+	requiredFields = map[string][]string{
+		"SpecStruct":    {"serviceName", "serviceRuntime"},
+		"ServiceConfig": {"spec"},
+	}
+	// Extra constraints that can't be derived from the go type, keyed by struct and field name
+	fieldConstraints = map[string]jsonSchema{
+		"SpecStruct.ServiceName":    {"pattern": utils.ValidNamePattern},
+		"SpecStruct.ServiceRunTime": {"enum": utils.GetSupportedRuntimes()},
+		"SpecStruct.Vars":           {"propertyNames": jsonSchema{"pattern": utils.ValidVarKeyPattern}},
+		"EnvironmentSpec.Vars":      {"propertyNames": jsonSchema{"pattern": utils.ValidVarKeyPattern}},
+	}
+)
+
+// Returns the JSON Schema of the nucleus manifest, generated from the config types
+func GetJsonSchema() ([]byte, error) {
+	schema, err := getTypeSchema(reflect.TypeOf(config.NucleusConfig{}))
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = jsonSchemaDraft
+	schema["$id"] = jsonSchemaId
+	schema["title"] = "Nucleus manifest"
+
+	output, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
+
+func getTypeSchema(t reflect.Type) (jsonSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return jsonSchema{"type": "string"}, nil
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}, nil
+	case reflect.Slice:
+		items, err := getTypeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return jsonSchema{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unable to generate schema for map with %s keys", t.Key().Kind())
+		}
+		values, err := getTypeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return jsonSchema{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		properties := jsonSchema{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := getYamlFieldName(field)
+			if name == "-" {
+				continue
+			}
+			fieldSchema, err := getTypeSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range fieldConstraints[fmt.Sprintf("%s.%s", t.Name(), field.Name)] {
+				fieldSchema[key] = value
+			}
+			properties[name] = fieldSchema
+		}
+		schema := jsonSchema{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required, ok := requiredFields[t.Name()]; ok {
+			schema["required"] = required
+		}
+		return schema, nil
+	default:
+		return nil, fmt.Errorf("unable to generate schema for %s", strings.ToLower(t.Kind().String()))
+	}
+}
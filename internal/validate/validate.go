@@ -0,0 +1,319 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nucleuscloud/cli/internal/config"
+	"github.com/nucleuscloud/cli/internal/utils"
+)
+
+// Issue describes a single problem found in a nucleus manifest.
+// Line and Column are 1-based and are 0 when the position is not known.
+type Issue struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i *Issue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+var (
+	typeErrorLineMatcher = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// Runs every offline check against the raw contents of a nucleus manifest.
+// An error is only returned if the manifest is not valid YAML.
+func ValidateManifest(data []byte) ([]*Issue, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, err
+	}
+	doc := getDocumentContent(&root)
+	if doc == nil {
+		return []*Issue{{Message: "manifest is empty"}}, nil
+	}
+
+	issues := findUnknownFields(doc, reflect.TypeOf(config.NucleusConfig{}), "")
+
+	cfg := config.NucleusConfig{}
+	err = doc.Decode(&cfg)
+	if err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		for _, msg := range typeErr.Errors {
+			issue := &Issue{Message: msg}
+			if matches := typeErrorLineMatcher.FindStringSubmatch(msg); matches != nil {
+				issue.Line, _ = strconv.Atoi(matches[1])
+				issue.Message = matches[2]
+			}
+			issues = append(issues, issue)
+		}
+		return sortIssues(issues), nil
+	}
+
+	issues = append(issues, validateConfig(doc, &cfg)...)
+	return sortIssues(issues), nil
+}
+
+func validateConfig(doc *yaml.Node, cfg *config.NucleusConfig) []*Issue {
+	issues := []*Issue{}
+
+	err := config.CheckCliVersion(cfg.CliVersion)
+	if err != nil {
+		issues = append(issues, newIssue(doc, "cliVersion", err.Error()))
+	}
+
+	_, err = config.GetServiceConfigs(cfg)
+	if err != nil {
+		issues = append(issues, newIssue(doc, "services", err.Error()))
+	}
+
+	if len(cfg.Services) == 0 {
+		issues = append(issues, validateSpec(doc, "spec", &cfg.Spec)...)
+	} else {
+		for idx := range cfg.Services {
+			path := fmt.Sprintf("services[%d].spec", idx)
+			issues = append(issues, validateSpec(doc, path, &cfg.Services[idx].Spec)...)
+		}
+	}
+	return issues
+}
+
+func validateSpec(doc *yaml.Node, path string, spec *config.SpecStruct) []*Issue {
+	issues := []*Issue{}
+
+	if spec.ServiceName == "" {
+		issues = append(issues, newIssue(doc, path+".serviceName", "service name not provided"))
+	} else if !utils.IsValidName(spec.ServiceName) {
+		issues = append(issues, newIssue(doc, path+".serviceName", "service name must start with a lowercase letter and only contain lowercase alphanumeric characters and hyphens"))
+	}
+
+	if spec.ServiceRunTime == "" {
+		issues = append(issues, newIssue(doc, path+".serviceRuntime", "service runtime not provided"))
+	} else if !utils.IsValidRuntime(spec.ServiceRunTime) {
+		issues = append(issues, newIssue(doc, path+".serviceRuntime", fmt.Sprintf("must provide valid service runtime (%s)", strings.Join(utils.GetSupportedRuntimes(), ", "))))
+	}
+
+	if spec.ServiceRunTime == "docker" && spec.Image == "" {
+		issues = append(issues, newIssue(doc, path+".image", "must provide image if service runtime is 'docker'"))
+	}
+
+	if err := ValidateResources(spec.Resources); err != nil {
+		issues = append(issues, newIssue(doc, path+".resources", err.Error()))
+	}
+
+	issues = append(issues, validateVarKeys(doc, path+".vars", spec.Vars)...)
+
+	envNames := []string{}
+	for envName := range spec.Environments {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+	for _, envName := range envNames {
+		envPath := fmt.Sprintf("%s.environments.%s", path, envName)
+		envSpec := config.GetSpecForEnv(spec, envName)
+		if err := ValidateResources(envSpec.Resources); err != nil {
+			issues = append(issues, newIssue(doc, envPath+".resources", err.Error()))
+		}
+		issues = append(issues, validateVarKeys(doc, envPath+".vars", spec.Environments[envName].Vars)...)
+	}
+	return issues
+}
+
+func validateVarKeys(doc *yaml.Node, path string, vars map[string]string) []*Issue {
+	issues := []*Issue{}
+	for key := range vars {
+		if err := utils.ValidateVarKey(key); err != nil {
+			issues = append(issues, newIssue(doc, joinPath(path, key), err.Error()))
+		}
+	}
+	return issues
+}
+
+// Walks the yaml tree alongside the go type it will be decoded into and reports any keys that have no matching field
+func findUnknownFields(node *yaml.Node, t reflect.Type, path string) []*Issue {
+	issues := []*Issue{}
+	if node == nil {
+		return issues
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return issues
+		}
+		fields := getYamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			fieldPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				issues = append(issues, &Issue{
+					Path:    fieldPath,
+					Line:    key.Line,
+					Column:  key.Column,
+					Message: fmt.Sprintf("unknown field '%s'", key.Value),
+				})
+				continue
+			}
+			issues = append(issues, findUnknownFields(node.Content[i+1], field.Type, fieldPath)...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return issues
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			issues = append(issues, findUnknownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return issues
+		}
+		for idx, item := range node.Content {
+			issues = append(issues, findUnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, idx))...)
+		}
+	}
+	return issues
+}
+
+// Returns the struct fields keyed by the name they have in yaml
+func getYamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := getYamlFieldName(field)
+		if name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func getYamlFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func newIssue(doc *yaml.Node, path string, message string) *Issue {
+	issue := &Issue{Path: path, Message: message}
+	if node := findClosestNode(doc, path); node != nil {
+		issue.Line = node.Line
+		issue.Column = node.Column
+	}
+	return issue
+}
+
+// Returns the node that the path points to.
+// For mapping entries this is the key so that editors highlight the field name.
+// If the path does not exist, the closest parent that does is returned.
+func findClosestNode(doc *yaml.Node, path string) *yaml.Node {
+	current := doc
+	closest := doc
+	for _, segment := range splitPath(path) {
+		key, value := getChildNode(current, segment)
+		if value == nil {
+			return closest
+		}
+		current = value
+		closest = value
+		if key != nil {
+			closest = key
+		}
+	}
+	return closest
+}
+
+// Returns the key (for mappings) and value of the child node that matches the path segment
+func getChildNode(node *yaml.Node, segment string) (*yaml.Node, *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i], node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		idx, err := strconv.Atoi(segment)
+		if err == nil && idx >= 0 && idx < len(node.Content) {
+			return nil, node.Content[idx]
+		}
+	}
+	return nil, nil
+}
+
+func splitPath(path string) []string {
+	segments := []string{}
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+		name, rest, hasIdx := strings.Cut(part, "[")
+		if name != "" {
+			segments = append(segments, name)
+		}
+		for hasIdx {
+			var idx string
+			idx, rest, _ = strings.Cut(rest, "]")
+			segments = append(segments, idx)
+			_, rest, hasIdx = strings.Cut(rest, "[")
+		}
+	}
+	return segments
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return fmt.Sprintf("%s.%s", path, key)
+}
+
+func getDocumentContent(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return node.Content[0]
+	}
+	if node.Kind == 0 {
+		return nil
+	}
+	return node
+}
+
+func sortIssues(issues []*Issue) []*Issue {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues
+}
@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nucleuscloud/cli/internal/config"
+)
+
+const (
+	validFilePath   = "./fixtures/valid.yaml"
+	invalidFilePath = "./fixtures/invalid.yaml"
+	schemaFilePath  = "../../schema/nucleus.schema.json"
+)
+
+func TestValidateManifest(t *testing.T) {
+	data, err := os.ReadFile(validFilePath)
+	assert.Nil(t, err)
+	issues, err := ValidateManifest(data)
+	assert.Nil(t, err)
+	assert.Empty(t, issues)
+
+	data, err = os.ReadFile(invalidFilePath)
+	assert.Nil(t, err)
+	issues, err = ValidateManifest(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Issue{
+		{Path: "spec.serviceName", Line: 3, Column: 3, Message: "service name must start with a lowercase letter and only contain lowercase alphanumeric characters and hyphens"},
+		{Path: "spec.serviceRuntime", Line: 4, Column: 3, Message: "must provide valid service runtime (go, nodejs, python, docker, ruby, java, dotnet)"},
+		{Path: "spec.isPrivat", Line: 5, Column: 3, Message: "unknown field 'isPrivat'"},
+		{Path: "spec.resources", Line: 6, Column: 3, Message: "min cpu must be less than max cpu"},
+		{Path: "spec.resources.maximum.gpu", Line: 11, Column: 7, Message: "unknown field 'gpu'"},
+		{Path: "spec.environments.prod.resources", Line: 14, Column: 7, Message: "minimum memory is not valid: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'"},
+		{Path: "spec.environments.prod.vars.MY-KEY", Line: 18, Column: 9, Message: "invalid var key 'MY-KEY': must start with a letter or underscore and only contain letters, digits and underscores"},
+	}, issues)
+}
+
+func TestValidateManifest_Errors(t *testing.T) {
+	_, err := ValidateManifest([]byte("spec: [\n"))
+	assert.Error(t, err)
+
+	issues, err := ValidateManifest([]byte(""))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Issue{{Message: "manifest is empty"}}, issues)
+
+	issues, err = ValidateManifest([]byte("spec:\n  isPrivate: maybe\n"))
+	assert.Nil(t, err)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, 2, issues[0].Line)
+}
+
+func TestValidateManifest_Services(t *testing.T) {
+	issues, err := ValidateManifest([]byte(`services:
+  - directory: ./foo
+    spec:
+      serviceName: foo
+      serviceRuntime: docker
+  - directory: ./foo2
+    spec:
+      serviceName: foo
+      serviceRuntime: go
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Issue{
+		{Path: "services", Line: 1, Column: 1, Message: "service 'foo' is defined more than once"},
+		{Path: "services[0].spec.image", Line: 3, Column: 5, Message: "must provide image if service runtime is 'docker'"},
+	}, issues)
+}
+
+func TestValidateResources(t *testing.T) {
+	assert.Nil(t, ValidateResources(config.ResourceRequirements{}))
+	assert.Nil(t, ValidateResources(config.ResourceRequirements{
+		Minimum: config.ResourceList{Cpu: "100m", Memory: "128Mi"},
+		Maximum: config.ResourceList{Cpu: "1", Memory: "1Gi"},
+	}))
+	assert.Error(t, ValidateResources(config.ResourceRequirements{
+		Minimum: config.ResourceList{Cpu: "abc"},
+	}))
+	assert.Error(t, ValidateResources(config.ResourceRequirements{
+		Minimum: config.ResourceList{Memory: "2Gi"},
+		Maximum: config.ResourceList{Memory: "1Gi"},
+	}))
+}
+
+func TestGetJsonSchema(t *testing.T) {
+	schema, err := GetJsonSchema()
+	assert.Nil(t, err)
+
+	published, err := os.ReadFile(schemaFilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(published), string(schema), "published schema is out of date, regenerate it with 'nucleus config schema > schema/nucleus.schema.json'")
+}